@@ -1,7 +1,9 @@
 package resources
 
 import (
-	"sync"
+	"context"
+	"log"
+	"sort"
 
 	"github.com/imle/resourcefork"
 )
@@ -28,6 +30,7 @@ type ResourceLibrary struct {
 	Picts map[PictID]*Pict
 	Ranks map[RankID]*Rank
 	RleDs map[RleDID]*RleD
+	Rle8s map[Rle8ID]*Rle8
 	Roids map[RoidID]*Roid
 	Shans map[ShanID]*Shan
 	Ships map[ShipID]*Ship
@@ -37,10 +40,14 @@ type ResourceLibrary struct {
 	StrAs map[StrAID]*StrA
 	Systs map[SystID]*Syst
 	Weaps map[WeapID]*Weap
+
+	images     *imageCache                // Decoded images, when the library was loaded lazily.
+	source     *resourcefork.ResourceFork // The raw resources the library was loaded from, for snapshots.
+	sourceHash []byte                     // See HashSources.
 }
 
-func NewResourceLibraryFromResourceFork(rf *resourcefork.ResourceFork) *ResourceLibrary {
-	rl := &ResourceLibrary{
+func newResourceLibrary() *ResourceLibrary {
+	return &ResourceLibrary{
 		Booms: map[BoomID]*Boom{},
 		Chars: map[CharID]*Char{},
 		Cicns: map[CicnID]*Cicn{},
@@ -59,6 +66,7 @@ func NewResourceLibraryFromResourceFork(rf *resourcefork.ResourceFork) *Resource
 		Picts: map[PictID]*Pict{},
 		Ranks: map[RankID]*Rank{},
 		RleDs: map[RleDID]*RleD{},
+		Rle8s: map[Rle8ID]*Rle8{},
 		Roids: map[RoidID]*Roid{},
 		Shans: map[ShanID]*Shan{},
 		Ships: map[ShipID]*Ship{},
@@ -69,114 +77,153 @@ func NewResourceLibraryFromResourceFork(rf *resourcefork.ResourceFork) *Resource
 		Systs: map[SystID]*Syst{},
 		Weaps: map[WeapID]*Weap{},
 	}
+}
 
-	rl.Colr = ColrFromResource(rf.Resources["cölr"][128])
-
-	for id := range rf.Resources["bööm"] {
-		rl.Booms[BoomID(id)] = BoomFromResource(rf.Resources["bööm"][id])
-	}
-	for id := range rf.Resources["chär"] {
-		rl.Chars[CharID(id)] = CharFromResource(rf.Resources["chär"][id])
-	}
-	for id := range rf.Resources["cicn"] {
-		rl.Cicns[CicnID(id)] = CicnFromResource(rf.Resources["cicn"][id])
-	}
-	for id := range rf.Resources["crön"] {
-		rl.Crons[CronID(id)] = CronFromResource(rf.Resources["crön"][id])
-	}
-	for id := range rf.Resources["dësc"] {
-		rl.Descs[DescID(id)] = DescFromResource(rf.Resources["dësc"][id])
-	}
-	for id := range rf.Resources["düde"] {
-		rl.Dudes[DudeID(id)] = DudeFromResource(rf.Resources["düde"][id])
-	}
-	for id := range rf.Resources["flët"] {
-		rl.Flets[FletID(id)] = FletFromResource(rf.Resources["flët"][id])
-	}
-	for id := range rf.Resources["gövt"] {
-		rl.Govts[GovtID(id)] = GovtFromResource(rf.Resources["gövt"][id])
-	}
-	for id := range rf.Resources["ïntf"] {
-		rl.Intfs[IntfID(id)] = IntfFromResource(rf.Resources["ïntf"][id])
-	}
-	for id := range rf.Resources["jünk"] {
-		rl.Junks[JunkID(id)] = JunkFromResource(rf.Resources["jünk"][id])
-	}
-	for id := range rf.Resources["mïsn"] {
-		rl.Misns[MisnID(id)] = MisnFromResource(rf.Resources["mïsn"][id])
-	}
-	for id := range rf.Resources["nëbu"] {
-		rl.Nebus[NebuID(id)] = NebuFromResource(rf.Resources["nëbu"][id])
-	}
-	for id := range rf.Resources["öops"] {
-		rl.Oopss[OopsID(id)] = OopsFromResource(rf.Resources["öops"][id])
-	}
-	for id := range rf.Resources["oütf"] {
-		rl.Outfs[OutfID(id)] = OutfFromResource(rf.Resources["oütf"][id])
+// NewResourceLibraryFromResourceFork decodes every resource in the fork. It exits the program if an image fails to
+// decode; use LoadResourceLibrary to handle errors, cancel loading or track progress.
+func NewResourceLibraryFromResourceFork(rf *resourcefork.ResourceFork) *ResourceLibrary {
+	rl, err := LoadResourceLibrary(context.Background(), rf, nil)
+	if err != nil {
+		log.Fatal(err)
 	}
-	for id := range rf.Resources["përs"] {
-		rl.Perss[PersID(id)] = PersFromResource(rf.Resources["përs"][id])
+
+	return rl
+}
+
+// ResourceName returns the name of the resource the library loaded for the type and ID, or an empty string if it
+// doesn't know it.
+func (rl *ResourceLibrary) ResourceName(typ string, id IDType) string {
+	if rl.source == nil {
+		return ""
 	}
-	lock := sync.Mutex{}
-	//finished := make(chan bool, 10)
-	wg := sync.WaitGroup{}
-	for id := range rf.Resources["PICT"] {
-		wg.Add(1)
-		go func(id uint16) {
-			temp := PictFromResource(rf.Resources["PICT"][id])
 
-			lock.Lock()
-			defer lock.Unlock()
-			rl.Picts[PictID(id)] = temp
-			wg.Add(-1)
-		}(id)
+	return rl.source.Resources[typ][uint16(id)].Name
+}
+
+// SystOfSpob finds the system whose nav defaults include the stellar, or nil if it isn't in any system.
+func (rl *ResourceLibrary) SystOfSpob(id SpobID) *Syst {
+	var found *Syst
+	for _, syst := range rl.Systs {
+		for _, spob := range syst.NavDef {
+			if spob == id && (found == nil || syst.ID < found.ID) {
+				found = syst
+			}
+		}
 	}
-	wg.Wait()
-	for id := range rf.Resources["ränk"] {
-		rl.Ranks[RankID(id)] = RankFromResource(rf.Resources["ränk"][id])
+
+	return found
+}
+
+// Adjacent reports whether two systems are directly linked by a hyperspace route.
+func (rl *ResourceLibrary) Adjacent(a, b SystID) bool {
+	if syst, ok := rl.Systs[a]; ok {
+		for _, c := range syst.Connection {
+			if c == b {
+				return true
+			}
+		}
+	}
+	if syst, ok := rl.Systs[b]; ok {
+		for _, c := range syst.Connection {
+			if c == a {
+				return true
+			}
+		}
 	}
-	lock = sync.Mutex{}
-	//finished = make(chan bool, 10)
-	wg = sync.WaitGroup{}
-	for id := range rf.Resources["rlëD"] {
-		wg.Add(1)
-		go func(id uint16) {
-			temp := RleDFromResource(rf.Resources["rlëD"][id])
 
-			lock.Lock()
-			defer lock.Unlock()
-			rl.RleDs[RleDID(id)] = temp
-			wg.Add(-1)
-		}(id)
+	return false
+}
+
+// Neighbors returns the systems linked to the given one by a hyperspace route, in ID order. Routes count in both
+// directions, as with Adjacent.
+func (rl *ResourceLibrary) Neighbors(id SystID) []SystID {
+	if _, ok := rl.Systs[id]; !ok {
+		return nil
 	}
-	wg.Wait()
-	for id := range rf.Resources["röid"] {
-		rl.Roids[RoidID(id)] = RoidFromResource(rf.Resources["röid"][id])
+
+	seen := map[SystID]bool{}
+	var out []SystID
+	add := func(other SystID) {
+		if _, ok := rl.Systs[other]; ok && other != id && !seen[other] {
+			seen[other] = true
+			out = append(out, other)
+		}
 	}
-	for id := range rf.Resources["shän"] {
-		rl.Shans[ShanID(id)] = ShanFromResource(rf.Resources["shän"][id])
+
+	for _, syst := range rl.Systs {
+		for _, c := range syst.Connection {
+			if syst.ID == id {
+				add(c)
+			} else if c == id {
+				add(syst.ID)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	return out
+}
+
+// links returns the neighbours of every system, as Neighbors would, from a single pass over the hyperspace routes.
+func (rl *ResourceLibrary) links() map[SystID][]SystID {
+	seen := map[[2]SystID]bool{}
+	out := make(map[SystID][]SystID, len(rl.Systs))
+	add := func(a, b SystID) {
+		if !seen[[2]SystID{a, b}] {
+			seen[[2]SystID{a, b}] = true
+			out[a] = append(out[a], b)
+		}
 	}
-	for id := range rf.Resources["shïp"] {
-		rl.Ships[ShipID(id)] = ShipFromResource(rf.Resources["shïp"][id])
+
+	for _, syst := range rl.Systs {
+		for _, c := range syst.Connection {
+			if _, ok := rl.Systs[c]; ok && c != syst.ID {
+				add(syst.ID, c)
+				add(c, syst.ID)
+			}
+		}
 	}
-	for id := range rf.Resources["snd"] {
-		rl.Snds[SndID(id)] = SndFromResource(rf.Resources["snd"][id])
+	for _, n := range out {
+		sort.Slice(n, func(i, j int) bool { return n[i] < n[j] })
 	}
-	for id := range rf.Resources["spïn"] {
-		rl.Spins[SpinID(id)] = SpinFromResource(rf.Resources["spïn"][id])
+
+	return out
+}
+
+// Route returns the shortest chain of jumps from one system to another, including both ends, or nil if there is no
+// way through. Ties go to the route through lower system IDs.
+func (rl *ResourceLibrary) Route(from, to SystID) []SystID {
+	if _, ok := rl.Systs[from]; !ok {
+		return nil
 	}
-	for id := range rf.Resources["spöb"] {
-		rl.Spobs[SpobID(id)] = SpobFromResource(rf.Resources["spöb"][id])
+	if _, ok := rl.Systs[to]; !ok {
+		return nil
 	}
-	for id := range rf.Resources["STR#"] {
-		rl.StrAs[StrAID(id)] = StrAFromResource(rf.Resources["STR#"][id])
+
+	links := rl.links()
+	prev := map[SystID]SystID{from: from}
+	queue := []SystID{from}
+	for len(queue) > 0 && queue[0] != to {
+		at := queue[0]
+		queue = queue[1:]
+
+		for _, next := range links[at] {
+			if _, seen := prev[next]; !seen {
+				prev[next] = at
+				queue = append(queue, next)
+			}
+		}
 	}
-	for id := range rf.Resources["sÿst"] {
-		rl.Systs[SystID(id)] = SystFromResource(rf.Resources["sÿst"][id])
+
+	if _, ok := prev[to]; !ok {
+		return nil
 	}
-	for id := range rf.Resources["wëap"] {
-		rl.Weaps[WeapID(id)] = WeapFromResource(rf.Resources["wëap"][id])
+
+	route := []SystID{to}
+	for at := to; at != from; {
+		at = prev[at]
+		route = append([]SystID{at}, route...)
 	}
 
-	return rl
+	return route
 }
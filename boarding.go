@@ -0,0 +1,201 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+)
+
+// When the player boards a disabled ship, what's aboard depends on the dude class the ship was created from (see
+// DudeBooty) and the ship itself. The calculator works out the expected haul:
+//
+//  Commodities  The ship carries one of its dude's commodities, each equally likely, in a quantity between nothing
+//               and CargoFraction of its Holds.
+//  Credits      Between nothing and CreditFraction of the ship's Cost.
+//  Ammunition   AmmoFraction of the ship's ammunition, for the weapons the boarding ship carries.
+//
+// Outfits are deliberately left out: Nova never hands over a boarded ship's outfits, and there's no booty flag for
+// them. The only way to get them is to capture the ship.
+//
+// Capture odds start at CaptureBase when the crews are the same size, scale with the ratio of the boarding crew to
+// the defending crew up to CaptureMax, and then each marines outfit adds its ModVal in percentage points.
+
+type BoardingCalculator struct {
+	Lib *ResourceLibrary
+
+	CargoFraction  float64
+	CreditFraction float64
+	AmmoFraction   float64
+	CaptureBase    float64
+	CaptureMax     float64
+}
+
+func NewBoardingCalculator(lib *ResourceLibrary) *BoardingCalculator {
+	return &BoardingCalculator{
+		Lib:            lib,
+		CargoFraction:  1,
+		CreditFraction: 0.1,
+		AmmoFraction:   1,
+		CaptureBase:    0.1,
+		CaptureMax:     0.9,
+	}
+}
+
+type Plunder struct {
+	Dude DudeID
+	Ship ShipID // Zero when the plunder is averaged over every ship class of the dude.
+	Govt GovtID
+
+	Boardable   bool                      // False if boarding parties are repelled.
+	Commodities map[CommodityType]float64 // Expected tons of each commodity.
+	Credits     float64                   // Expected credits.
+	Ammo        map[WeapID]float64        // Expected ammunition, by the wëap it supplies.
+
+	CaptureOdds    float64 // Chance of capturing the ship, from 0 to 1.
+	DisablePenalty int16   // Legal status change for disabling the ship first, from its govt's DisabPenalty.
+	BoardPenalty   int16   // Legal status change for boarding it, from its govt's BoardPenalty.
+}
+
+// Plunder returns the expected plunder from boarding a ship of the given class created from the dude, by the
+// boarding loadout.
+func (c *BoardingCalculator) Plunder(dude DudeID, ship ShipID, boarder *Loadout) (*Plunder, error) {
+	d, ok := c.Lib.Dudes[dude]
+	if !ok {
+		return nil, fmt.Errorf("unknown düde %d", dude)
+	}
+	s, ok := c.Lib.Ships[ship]
+	if !ok {
+		return nil, fmt.Errorf("unknown shïp %d", ship)
+	}
+
+	p := &Plunder{
+		Dude:        dude,
+		Ship:        ship,
+		Govt:        d.Govt,
+		Boardable:   d.Booty.Boardable(),
+		Commodities: map[CommodityType]float64{},
+		Ammo:        map[WeapID]float64{},
+	}
+
+	if g, ok := c.Lib.Govts[d.Govt]; ok {
+		p.DisablePenalty = g.Penalty(LegalActionDisable)
+		p.BoardPenalty = g.Penalty(LegalActionBoard)
+	}
+
+	odds, err := c.captureOdds(s, boarder)
+	if err != nil {
+		return nil, err
+	}
+	p.CaptureOdds = odds
+
+	if !p.Boardable {
+		return p, nil
+	}
+
+	if commodities := d.Booty.Commodities(); len(commodities) > 0 {
+		each := float64(s.Holds) * c.CargoFraction / 2 / float64(len(commodities))
+		for _, t := range commodities {
+			p.Commodities[t] = each
+		}
+	}
+
+	if d.Booty.CarriesMoney {
+		p.Credits = float64(s.Cost) * c.CreditFraction / 2
+	}
+
+	if d.Booty.CarriesAmmo {
+		guns, _, err := boarder.Weapons(c.Lib)
+		if err != nil {
+			return nil, err
+		}
+		_, aboard, err := NewLoadout(c.Lib, ship).Weapons(c.Lib)
+		if err != nil {
+			return nil, err
+		}
+
+		for id := range guns {
+			ammoID, ok := c.Lib.Weaps[id].AmmoID()
+			if ok && aboard[ammoID] > 0 {
+				p.Ammo[ammoID] = float64(aboard[ammoID]) * c.AmmoFraction
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Expected returns the plunder averaged over the dude's ship classes, weighted by their probabilities.
+func (c *BoardingCalculator) Expected(dude DudeID, boarder *Loadout) (*Plunder, error) {
+	d, ok := c.Lib.Dudes[dude]
+	if !ok {
+		return nil, fmt.Errorf("unknown düde %d", dude)
+	}
+
+	out := &Plunder{
+		Dude:        dude,
+		Govt:        d.Govt,
+		Boardable:   d.Booty.Boardable(),
+		Commodities: map[CommodityType]float64{},
+		Ammo:        map[WeapID]float64{},
+	}
+
+	total := 0
+	for i, ship := range d.ShipType {
+		if ship > 0 && d.Probability[i] > 0 {
+			total += int(d.Probability[i])
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("düde %d has no ship classes", dude)
+	}
+
+	for i, ship := range d.ShipType {
+		if ship <= 0 || d.Probability[i] <= 0 {
+			continue
+		}
+
+		p, err := c.Plunder(dude, ship, boarder)
+		if err != nil {
+			return nil, err
+		}
+
+		w := float64(d.Probability[i]) / float64(total)
+		for t, v := range p.Commodities {
+			out.Commodities[t] += v * w
+		}
+		for id, v := range p.Ammo {
+			out.Ammo[id] += v * w
+		}
+		out.Credits += p.Credits * w
+		out.CaptureOdds += p.CaptureOdds * w
+		out.DisablePenalty, out.BoardPenalty = p.DisablePenalty, p.BoardPenalty
+	}
+
+	return out, nil
+}
+
+func (c *BoardingCalculator) captureOdds(target *Ship, boarder *Loadout) (float64, error) {
+	ship, ok := c.Lib.Ships[boarder.Ship]
+	if !ok {
+		return 0, fmt.Errorf("unknown shïp %d", boarder.Ship)
+	}
+
+	odds := c.CaptureMax
+	if target.Crew > 0 {
+		odds = math.Min(c.CaptureBase*float64(ship.Crew)/float64(target.Crew), c.CaptureMax)
+	}
+
+	for id, n := range boarder.Outfits {
+		outf, ok := c.Lib.Outfs[id]
+		if !ok {
+			return 0, fmt.Errorf("unknown oütf %d", id)
+		}
+
+		for _, mod := range outf.ModType {
+			if mod.OutfModType() == OutfModTypeMarines {
+				odds += float64(mod.OutfModValue()) * float64(n) / 100
+			}
+		}
+	}
+
+	return math.Max(0, math.Min(odds, 1)), nil
+}
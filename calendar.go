@@ -0,0 +1,388 @@
+package resources
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Nova advances its calendar one day per jump or landing. Each day every crön resource moves through the following
+// phases:
+//
+//  Idle         Waiting for its date window, EnableOn and Require to allow it, then for its Random roll to succeed.
+//  PreHoldoff   Activated, waiting PreHoldoff days before it starts.
+//  Running      OnStart has been run. The cron contributes its bits and shows its news for Duration days.
+//  PostHoldoff  OnEnd has been run. The cron waits PostHoldoff days before it can be activated again.
+
+// NovaDate converts a day, month and year as stored in the data files into a time.Time.
+func NovaDate(day, month, year int16) time.Time {
+	return time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC)
+}
+
+type CronPhase int8
+
+const (
+	CronPhaseIdle CronPhase = iota
+	CronPhasePreHoldoff
+	CronPhaseRunning
+	CronPhasePostHoldoff
+)
+
+func (p CronPhase) String() string {
+	switch p {
+	case CronPhasePreHoldoff:
+		return "PreHoldoff"
+	case CronPhaseRunning:
+		return "Running"
+	case CronPhasePostHoldoff:
+		return "PostHoldoff"
+	default:
+		return "Idle"
+	}
+}
+
+type CronEventKind int8
+
+const (
+	CronEventActivated   CronEventKind = iota // Passed its Random roll and entered PreHoldoff.
+	CronEventStarted                          // OnStart was run.
+	CronEventEnded                            // OnEnd was run.
+	CronEventDeactivated                      // Finished PostHoldoff and is eligible again.
+)
+
+func (k CronEventKind) String() string {
+	switch k {
+	case CronEventStarted:
+		return "Started"
+	case CronEventEnded:
+		return "Ended"
+	case CronEventDeactivated:
+		return "Deactivated"
+	default:
+		return "Activated"
+	}
+}
+
+type CronEvent struct {
+	Date time.Time
+	Cron CronID
+	Kind CronEventKind
+	Err  error // Set if the hook failed while running OnStart or OnEnd.
+}
+
+type CronNews struct {
+	Cron    CronID
+	StrA    StrAID
+	Local   bool     // The news comes from a GovtNewsStr rather than IndNewsStr.
+	Strings []string // The candidate strings, one of which Nova shows at random.
+}
+
+type CronDay struct {
+	Date    time.Time
+	Events  []CronEvent
+	Running []CronID
+	News    []CronNews // Only populated when a spöb is given to Run.
+}
+
+type cronState struct {
+	phase     CronPhase
+	remaining int
+}
+
+type CronSimulator struct {
+	Lib        *ResourceLibrary
+	Relations  *GovtRelations
+	State      ControlBitWriter
+	Contribute FlagMask64     // The player's contribute bits from their ship and outfits.
+	Hook       ControlBitHook // Runs OnStart and OnEnd.
+	Rand       *rand.Rand
+
+	// Continuous crons re-run their scripts while they remain eligible. This caps the number of iterations so that
+	// a badly written cron can't hang the simulator.
+	MaxIterations int
+
+	Date time.Time
+
+	ids     []CronID
+	states  map[CronID]*cronState
+	started bool
+}
+
+func NewCronSimulator(lib *ResourceLibrary, state ControlBitWriter, start time.Time, seed int64) *CronSimulator {
+	if state == nil {
+		state = &ControlBits{}
+	}
+
+	s := &CronSimulator{
+		Lib:           lib,
+		Relations:     NewGovtRelations(lib.Govts),
+		State:         state,
+		Hook:          DefaultControlBitHook,
+		Rand:          rand.New(rand.NewSource(seed)),
+		MaxIterations: 100,
+		Date:          start,
+		states:        map[CronID]*cronState{},
+	}
+
+	for id := range lib.Crons {
+		s.ids = append(s.ids, id)
+		s.states[id] = &cronState{}
+	}
+	sort.Slice(s.ids, func(i, j int) bool { return s.ids[i] < s.ids[j] })
+
+	return s
+}
+
+// NewCronSimulatorFromChar starts the simulation on the chär resource's starting date.
+func NewCronSimulatorFromChar(lib *ResourceLibrary, c *Char, state ControlBitWriter, seed int64) *CronSimulator {
+	return NewCronSimulator(lib, state, c.StartTime(), seed)
+}
+
+func (s *CronSimulator) Phase(id CronID) CronPhase {
+	if st, ok := s.states[id]; ok {
+		return st.phase
+	}
+
+	return CronPhaseIdle
+}
+
+// Running returns the crons that are currently running, in ID order.
+func (s *CronSimulator) Running() []CronID {
+	var out []CronID
+	for _, id := range s.ids {
+		if s.states[id].phase == CronPhaseRunning {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+// contribute combines the player's contribute bits with those of every running cron.
+func (s *CronSimulator) contribute() FlagMask64 {
+	c := s.Contribute
+	for _, id := range s.ids {
+		if s.states[id].phase == CronPhaseRunning {
+			c |= s.Lib.Crons[id].Contribute
+		}
+	}
+
+	return c
+}
+
+func (s *CronSimulator) enabled(c *Cron) bool {
+	return c.Require&s.contribute() == c.Require && c.EnableOn.Test(s.State)
+}
+
+// InWindow reports whether the date lies within the cron's First and Last date fields. Fields set to 0 or -1 are
+// wildcards. Windows whose last date comes before their first (e.g. November to February) wrap around.
+func (c Cron) InWindow(date time.Time) bool {
+	d := [3]int{date.Year(), int(date.Month()), date.Day()}
+	first := [3]int16{c.FirstYear, c.FirstMonth, c.FirstDay}
+	last := [3]int16{c.LastYear, c.LastMonth, c.LastDay}
+
+	afterFirst := compareDateFields(d, first) >= 0
+	beforeLast := compareDateFields(d, last) <= 0
+
+	if compareBounds(first, last) > 0 {
+		return afterFirst || beforeLast
+	}
+
+	return afterFirst && beforeLast
+}
+
+// compareDateFields compares a date to a bound, most significant field first, skipping wildcard fields.
+func compareDateFields(d [3]int, bound [3]int16) int {
+	for i := range d {
+		if bound[i] <= 0 {
+			continue
+		}
+		if d[i] < int(bound[i]) {
+			return -1
+		}
+		if d[i] > int(bound[i]) {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// compareBounds compares two bounds on the fields that neither leaves as a wildcard.
+func compareBounds(a, b [3]int16) int {
+	for i := range a {
+		if a[i] <= 0 || b[i] <= 0 {
+			continue
+		}
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func (s *CronSimulator) run(day *CronDay, id CronID, kind CronEventKind, f ControlBitFunction, continuous bool) {
+	c := s.Lib.Crons[id]
+
+	err := s.Hook(f, s.State, s.Rand)
+	for i := 1; err == nil && continuous && i < s.MaxIterations && s.enabled(c); i++ {
+		err = s.Hook(f, s.State, s.Rand)
+	}
+
+	day.Events = append(day.Events, CronEvent{Date: s.Date, Cron: id, Kind: kind, Err: err})
+}
+
+func (s *CronSimulator) start(day *CronDay, id CronID) {
+	c := s.Lib.Crons[id]
+	st := s.states[id]
+
+	st.phase = CronPhaseRunning
+	st.remaining = int(c.Duration)
+	s.run(day, id, CronEventStarted, c.OnStart, c.Flags.ContinuousEntry)
+
+	if st.remaining <= 0 {
+		s.end(day, id)
+	}
+}
+
+func (s *CronSimulator) end(day *CronDay, id CronID) {
+	c := s.Lib.Crons[id]
+	st := s.states[id]
+
+	st.phase = CronPhasePostHoldoff
+	st.remaining = int(c.PostHoldoff)
+	s.run(day, id, CronEventEnded, c.OnEnd, c.Flags.ContinuousExit)
+
+	if st.remaining <= 0 {
+		s.deactivate(day, id)
+	}
+}
+
+func (s *CronSimulator) deactivate(day *CronDay, id CronID) {
+	s.states[id].phase = CronPhaseIdle
+	day.Events = append(day.Events, CronEvent{Date: s.Date, Cron: id, Kind: CronEventDeactivated})
+}
+
+// Step advances the calendar by one day and processes every cron.
+func (s *CronSimulator) Step() CronDay {
+	s.Date = s.Date.AddDate(0, 0, 1)
+	s.started = true
+	return s.process()
+}
+
+func (s *CronSimulator) process() CronDay {
+	day := CronDay{Date: s.Date}
+
+	// Crons that are already in progress count down first.
+	for _, id := range s.ids {
+		st := s.states[id]
+
+		switch st.phase {
+		case CronPhasePreHoldoff:
+			st.remaining--
+			if st.remaining <= 0 {
+				s.start(&day, id)
+			}
+		case CronPhaseRunning:
+			st.remaining--
+			if st.remaining <= 0 {
+				s.end(&day, id)
+			}
+		case CronPhasePostHoldoff:
+			st.remaining--
+			if st.remaining <= 0 {
+				s.deactivate(&day, id)
+			}
+		}
+	}
+
+	// Then idle crons get a chance to activate.
+	for _, id := range s.ids {
+		st := s.states[id]
+		c := s.Lib.Crons[id]
+
+		if st.phase != CronPhaseIdle || !c.InWindow(s.Date) || !s.enabled(c) {
+			continue
+		}
+		if c.Random < 100 && s.Rand.Intn(100) >= int(c.Random) {
+			continue
+		}
+
+		day.Events = append(day.Events, CronEvent{Date: s.Date, Cron: id, Kind: CronEventActivated})
+
+		if c.PreHoldoff > 0 {
+			st.phase = CronPhasePreHoldoff
+			st.remaining = int(c.PreHoldoff)
+		} else {
+			s.start(&day, id)
+		}
+	}
+
+	day.Running = s.Running()
+
+	return day
+}
+
+// News returns the news that would be shown on the spöb for the crons that are currently running. Local news from a
+// NewsGovt that the spöb's govt is allied with takes precedence over independent news.
+func (s *CronSimulator) News(spob *Spob) []CronNews {
+	var out []CronNews
+
+	for _, id := range s.Running() {
+		c := s.Lib.Crons[id]
+
+		news := CronNews{Cron: id, StrA: -1}
+		for i, govt := range c.NewsGovt {
+			if govt <= 0 || c.GovtNewsStr[i] <= 0 {
+				continue
+			}
+			if s.Relations.IsAlly(govt, spob.Govt) {
+				news.StrA = c.GovtNewsStr[i]
+				news.Local = true
+				break
+			}
+		}
+
+		if !news.Local {
+			if c.IndNewsStr <= 0 {
+				continue
+			}
+			news.StrA = c.IndNewsStr
+		}
+
+		if strs, ok := s.Lib.StrAs[news.StrA]; ok {
+			news.Strings = append(news.Strings, strs.Values...)
+		}
+
+		out = append(out, news)
+	}
+
+	return out
+}
+
+// Run advances the simulation by the given number of days, the first of which is the starting date itself. If spob
+// is not nil, each day also reports the news that would be shown there.
+func (s *CronSimulator) Run(days int, spob *Spob) []CronDay {
+	out := make([]CronDay, 0, days)
+
+	for i := 0; i < days; i++ {
+		var day CronDay
+		if s.started {
+			day = s.Step()
+		} else {
+			day = s.process()
+			s.started = true
+		}
+
+		if spob != nil {
+			day.News = s.News(spob)
+		}
+		out = append(out, day)
+	}
+
+	return out
+}
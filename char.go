@@ -106,3 +106,7 @@ func CharFromBytes(id CharID, b []byte) *Char {
 
 	return t
 }
+
+func (c Char) StartTime() time.Time {
+	return NovaDate(c.StartDate, c.StartMonth, c.StartYear)
+}
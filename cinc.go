@@ -13,6 +13,8 @@ type CicnID IDType
 type Cicn struct {
 	ID    CicnID
 	Image image.Image
+
+	data []byte // The undecoded resource, when the library was loaded lazily.
 }
 
 func CicnFromResource(resource resourcefork.Resource) *Cicn {
@@ -84,21 +84,16 @@ func ColrFromBytes(id ColrID, b []byte) *Colr {
 		MenuColor2:   color.RGBA{A: b[82], R: b[83], G: b[84], B: b[85]},
 		GridBright:   color.RGBA{A: b[86], R: b[87], G: b[88], B: b[89]},
 		GridDim:      color.RGBA{A: b[90], R: b[91], G: b[92], B: b[93]},
-		ProgressBar: image.Rect(
-			int(int16(binary.BigEndian.Uint16(b[94:]))),
-			int(int16(binary.BigEndian.Uint16(b[96:]))),
-			int(int16(binary.BigEndian.Uint16(b[98:]))),
-			int(int16(binary.BigEndian.Uint16(b[100:]))),
-		),
+		ProgressBar:  quickDrawRect(b[94:]),
 		ProgBright:   color.RGBA{A: b[102], R: b[103], G: b[104], B: b[105]},
 		ProgDim:      color.RGBA{A: b[106], R: b[107], G: b[108], B: b[109]},
 		ProgOutline:  color.RGBA{A: b[110], R: b[111], G: b[112], B: b[113]},
-		Button1:      image.Point{X: int(b[114]), Y: int(b[116])},
-		Button2:      image.Point{X: int(b[118]), Y: int(b[120])},
-		Button3:      image.Point{X: int(b[122]), Y: int(b[124])},
-		Button4:      image.Point{X: int(b[126]), Y: int(b[128])},
-		Button5:      image.Point{X: int(b[130]), Y: int(b[132])},
-		Button6:      image.Point{X: int(b[134]), Y: int(b[136])},
+		Button1:      colrPoint(b[114:]),
+		Button2:      colrPoint(b[118:]),
+		Button3:      colrPoint(b[122:]),
+		Button4:      colrPoint(b[126:]),
+		Button5:      colrPoint(b[130:]),
+		Button6:      colrPoint(b[134:]),
 		FloatingMap:  color.RGBA{A: b[138], R: b[139], G: b[140], B: b[141]},
 		ListText:     color.RGBA{A: b[142], R: b[143], G: b[144], B: b[145]},
 		ListBkgnd:    color.RGBA{A: b[146], R: b[147], G: b[148], B: b[149]},
@@ -106,12 +101,19 @@ func ColrFromBytes(id ColrID, b []byte) *Colr {
 		EscortHilite: color.RGBA{A: b[154], R: b[155], G: b[156], B: b[157]},
 		ButtonFont:   byteString(b[158:], 63),
 		ButtonFontSz: int16(binary.BigEndian.Uint16(b[222:])),
-		Logo:         image.Point{X: int(b[224]), Y: int(b[226])},
-		Rollover:     image.Point{X: int(b[228]), Y: int(b[230])},
-		Slide1:       image.Point{X: int(b[232]), Y: int(b[234])},
-		Slide2:       image.Point{X: int(b[236]), Y: int(b[238])},
-		Slide3:       image.Point{X: int(b[240]), Y: int(b[242])},
+		Logo:         colrPoint(b[224:]),
+		Rollover:     colrPoint(b[228:]),
+		Slide1:       colrPoint(b[232:]),
+		Slide2:       colrPoint(b[236:]),
+		Slide3:       colrPoint(b[240:]),
 	}
 
 	return t
 }
+
+func colrPoint(b []byte) image.Point {
+	return image.Point{
+		X: int(int16(binary.BigEndian.Uint16(b[0:]))),
+		Y: int(int16(binary.BigEndian.Uint16(b[2:]))),
+	}
+}
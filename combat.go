@@ -0,0 +1,660 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// The combat simulator flies two ships at each other in two dimensions, one frame (1/30 s) at a time, until one of
+// them is disabled or time runs out. It's a balancing aid rather than a reproduction of Nova's AI, and uses the units
+// the resource documentation gives:
+//
+//  Ship Speed, wëap Speed      100 = 1 pixel per frame
+//  Ship Accel                  100 = 1 pixel per frame per second
+//  Ship Maneuver               10 = 30 degrees per second
+//  ShieldRech, ArmorRech       1000 = 30 points per second
+//  Deionize                    100 = 1 point of ionization per frame
+//  wëap Reload, Count          Frames
+//
+// While its shields are up a ship's shields take a shot's energy damage plus half its mass damage; once they're down
+// its armour takes the mass damage plus half the energy damage, and a shot that knocks the shields down carries
+// through to the armour in proportion. IgnoreShields weapons always hit the armour. A ship
+// whose ionization reaches its IonizeMax is ionized: it moves and turns at half rate and can't fire weapons that
+// can't be fired while ionized. A ship is disabled when its armour falls to a third of its maximum, or a tenth with
+// DisableAt10Percent.
+
+const (
+	combatFramesPerSecond = 30
+	combatMinRadius       = 8 // Smallest hit radius, for ships with no length set.
+	combatDefaultTurn     = 2 // Degrees per frame homing weapons turn when GuidedTurn isn't set.
+)
+
+// Loadout is a ship as it goes into a fight.
+type Loadout struct {
+	Ship    ShipID
+	Outfits map[OutfID]int16 // Installed outfits, on top of the ship's inherent weapons.
+}
+
+// NewLoadout returns the ship with the outfits it's sold with.
+func NewLoadout(lib *ResourceLibrary, id ShipID) *Loadout {
+	l := &Loadout{Ship: id, Outfits: map[OutfID]int16{}}
+
+	if ship, ok := lib.Ships[id]; ok {
+		for i, item := range ship.DefaultItems {
+			if item > 0 && ship.ItemCount[i] > 0 {
+				l.Outfits[item] += ship.ItemCount[i]
+			}
+		}
+		for i, item := range ship.DefaultItems2 {
+			if item > 0 && ship.ItemCount2[i] > 0 {
+				l.Outfits[item] += ship.ItemCount2[i]
+			}
+		}
+	}
+
+	return l
+}
+
+// Weapons totals the loadout's guns by weapon and its ammunition by the wëap it supplies, from both the ship's
+// inherent weapons and its outfits.
+func (l *Loadout) Weapons(lib *ResourceLibrary) (guns map[WeapID]int, ammo map[WeapID]int, err error) {
+	ship, ok := lib.Ships[l.Ship]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown shïp %d", l.Ship)
+	}
+
+	guns, ammo = map[WeapID]int{}, map[WeapID]int{}
+
+	// Ammunition is recorded against the weapon that fires it.
+	addAmmo := func(launcher WeapID, n int) error {
+		weap, ok := lib.Weaps[launcher]
+		if !ok {
+			return fmt.Errorf("unknown wëap %d", launcher)
+		}
+		if id, ok := weap.AmmoID(); ok {
+			ammo[id] += n
+		}
+		return nil
+	}
+
+	for i, id := range ship.WeapType {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := lib.Weaps[id]; !ok {
+			return nil, nil, fmt.Errorf("unknown wëap %d", id)
+		}
+
+		guns[id] += int(ship.WeapCount[i])
+		if ship.AmmoLoad[i] > 0 {
+			if err := addAmmo(id, int(ship.AmmoLoad[i])); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	for id, n := range l.Outfits {
+		outf, ok := lib.Outfs[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown oütf %d", id)
+		}
+
+		for _, mod := range outf.ModType {
+			switch mod.OutfModType() {
+			case OutfModTypeWeapon:
+				if _, ok := lib.Weaps[WeapID(mod.OutfModValue())]; !ok {
+					return nil, nil, fmt.Errorf("unknown wëap %d", mod.OutfModValue())
+				}
+				guns[WeapID(mod.OutfModValue())] += int(n)
+			case OutfModTypeAmmunition:
+				if err := addAmmo(WeapID(mod.OutfModValue()), int(n)); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	return guns, ammo, nil
+}
+
+type CombatSimulator struct {
+	Lib *ResourceLibrary
+
+	MaxTime       time.Duration // Fights still going after this long are draws.
+	StartDistance float64       // Pixels between the ships when the fight starts.
+}
+
+func NewCombatSimulator(lib *ResourceLibrary) *CombatSimulator {
+	return &CombatSimulator{
+		Lib:           lib,
+		MaxTime:       3 * time.Minute,
+		StartDistance: 1200,
+	}
+}
+
+// CombatDamage is the damage one side dealt to the other.
+type CombatDamage struct {
+	Shield     float64
+	Armour     float64
+	Ionization float64
+	ByWeap     map[WeapID]float64 // Shield and armour damage, by the weapon that dealt it.
+
+	Shots int
+	Hits  int
+}
+
+func (d *CombatDamage) add(o CombatDamage) {
+	d.Shield += o.Shield
+	d.Armour += o.Armour
+	d.Ionization += o.Ionization
+	d.Shots += o.Shots
+	d.Hits += o.Hits
+	for id, v := range o.ByWeap {
+		d.ByWeap[id] += v
+	}
+}
+
+type CombatResult struct {
+	Winner int // 0 or 1 for the loadout that disabled the other, or -1 if neither managed to in time.
+	Time   time.Duration
+	Damage [2]CombatDamage // Damage dealt by each loadout.
+}
+
+type CombatReport struct {
+	Runs  int
+	Wins  [2]int
+	Draws int
+
+	TimeToDisable [2]time.Duration // Mean time each loadout took to disable the other, over the fights it won.
+	Damage        [2]CombatDamage  // Damage dealt by each loadout, summed over every run.
+}
+
+// WinRate is the fraction of runs the loadout (0 or 1) won.
+func (r *CombatReport) WinRate(side int) float64 {
+	if r.Runs == 0 {
+		return 0
+	}
+
+	return float64(r.Wins[side]) / float64(r.Runs)
+}
+
+// Run fights the loadouts against each other the given number of times. Each fight gets its own seed drawn from the
+// one given, so the whole report is reproducible.
+func (s *CombatSimulator) Run(a, b *Loadout, runs int, seed int64) (*CombatReport, error) {
+	rng := rand.New(rand.NewSource(seed))
+
+	r := &CombatReport{Runs: runs}
+	r.Damage[0].ByWeap = map[WeapID]float64{}
+	r.Damage[1].ByWeap = map[WeapID]float64{}
+
+	var disableTime [2]time.Duration
+	for i := 0; i < runs; i++ {
+		res, err := s.Fight(a, b, rng.Int63())
+		if err != nil {
+			return nil, err
+		}
+
+		if res.Winner < 0 {
+			r.Draws++
+		} else {
+			r.Wins[res.Winner]++
+			disableTime[res.Winner] += res.Time
+		}
+		r.Damage[0].add(res.Damage[0])
+		r.Damage[1].add(res.Damage[1])
+	}
+
+	for side := range r.Wins {
+		if r.Wins[side] > 0 {
+			r.TimeToDisable[side] = disableTime[side] / time.Duration(r.Wins[side])
+		}
+	}
+
+	return r, nil
+}
+
+// Fight runs a single fight.
+func (s *CombatSimulator) Fight(a, b *Loadout, seed int64) (*CombatResult, error) {
+	rng := rand.New(rand.NewSource(seed))
+
+	var ships [2]*combatShip
+	for i, l := range []*Loadout{a, b} {
+		c, err := s.newCombatShip(l)
+		if err != nil {
+			return nil, err
+		}
+		ships[i] = c
+	}
+
+	// Start facing roughly towards each other across the start distance.
+	bearing := rng.Float64() * 2 * math.Pi
+	ships[1].x, ships[1].y = s.StartDistance*math.Cos(bearing), s.StartDistance*math.Sin(bearing)
+	ships[0].heading = bearing + (rng.Float64()-0.5)*math.Pi/2
+	ships[1].heading = bearing + math.Pi + (rng.Float64()-0.5)*math.Pi/2
+
+	res := &CombatResult{Winner: -1}
+	res.Damage[0].ByWeap = map[WeapID]float64{}
+	res.Damage[1].ByWeap = map[WeapID]float64{}
+
+	var shots []*combatShot
+	maxFrames := int(s.MaxTime * combatFramesPerSecond / time.Second)
+
+	frame := 0
+	for ; frame < maxFrames; frame++ {
+		for i, c := range ships {
+			c.fly(ships[1-i])
+		}
+		for i, c := range ships {
+			shots = append(shots, c.fire(i, ships[1-i], rng, &res.Damage[i])...)
+		}
+
+		live := shots[:0]
+		for _, shot := range shots {
+			target := ships[1-shot.side]
+			if shot.move(target) {
+				target.hit(shot.weap, &res.Damage[shot.side])
+				continue
+			}
+			if shot.life > 0 {
+				live = append(live, shot)
+			}
+		}
+		shots = live
+
+		for _, c := range ships {
+			c.recharge()
+		}
+
+		disabled := [2]bool{ships[0].disabled(), ships[1].disabled()}
+		if disabled[0] || disabled[1] {
+			if disabled[0] != disabled[1] {
+				res.Winner = 0
+				if disabled[0] {
+					res.Winner = 1
+				}
+			}
+			frame++
+			break
+		}
+	}
+
+	res.Time = time.Duration(frame) * time.Second / combatFramesPerSecond
+
+	return res, nil
+}
+
+type combatWeapon struct {
+	weap  *Weap
+	count int
+	ammo  *int // Shared by every weapon firing the same ammunition, nil if the weapon doesn't need any.
+
+	reload int // Frames until the weapon can fire again.
+	burst  int // Shots fired since the last burst reload.
+}
+
+type combatShip struct {
+	ship *Ship
+
+	shield, maxShield float64
+	armour, maxArmour float64
+	shieldRech        float64 // Points per frame.
+	armourRech        float64
+	ionization        float64
+	ionizeMax         float64
+	deionize          float64 // Points per frame.
+
+	speed  float64 // Pixels per frame.
+	accel  float64 // Pixels per frame per frame.
+	turn   float64 // Radians per frame.
+	radius float64
+
+	weapons []*combatWeapon
+	reach   float64 // Range of the longest reaching weapon.
+
+	x, y, vx, vy float64
+	heading      float64
+}
+
+func (s *CombatSimulator) newCombatShip(l *Loadout) (*combatShip, error) {
+	ship, ok := s.Lib.Ships[l.Ship]
+	if !ok {
+		return nil, fmt.Errorf("unknown shïp %d", l.Ship)
+	}
+
+	shield, armour := float64(ship.Shield), float64(ship.Armour)
+	shieldRech, armourRech := float64(ship.ShieldRech), float64(ship.ArmorRech)
+	deionize, ionizeMax := float64(ship.Deionize), float64(ship.IonizeMax)
+	speed, accel, maneuver := float64(ship.Speed), float64(ship.Accel), float64(ship.Maneuver)
+
+	guns, ammo, err := l.Weapons(s.Lib)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, count := range l.Outfits {
+		outf, ok := s.Lib.Outfs[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown oütf %d", id)
+		}
+
+		n := float64(count)
+		for _, mod := range outf.ModType {
+			v := float64(mod.OutfModValue())
+
+			switch mod.OutfModType() {
+			case OutfModTypeShieldCapacity:
+				shield += v * n
+			case OutfModTypeShieldRechargeSpeed:
+				shieldRech += v * n
+			case OutfModTypeArmour:
+				armour += v * n
+			case OutfModTypeFasterArmourRecharge:
+				armourRech += v * n
+			case OutfModTypeAccelerationBooster:
+				accel += v * n
+			case OutfModTypeSpeedIncrease:
+				speed += v * n
+			case OutfModTypeTurnRateChange:
+				maneuver += v * n
+			case OutfModTypeIonDissipater:
+				deionize += v * n
+			case OutfModTypeIonAbsorber:
+				ionizeMax += v * n
+			}
+		}
+	}
+
+	c := &combatShip{
+		ship:       ship,
+		shield:     math.Max(shield, 0),
+		maxShield:  math.Max(shield, 0),
+		armour:     math.Max(armour, 1),
+		maxArmour:  math.Max(armour, 1),
+		shieldRech: shieldRech / 1000,
+		armourRech: armourRech / 1000,
+		ionizeMax:  ionizeMax,
+		deionize:   deionize / 100,
+		speed:      math.Max(speed, 0) / 100,
+		accel:      math.Max(accel, 0) / 100 / combatFramesPerSecond,
+		turn:       math.Max(maneuver, 0) * 3 / combatFramesPerSecond * math.Pi / 180,
+		radius:     math.Max(float64(ship.Length)/2, combatMinRadius),
+	}
+
+	// Weapons sharing ammunition share the same supply of it.
+	pools := map[WeapID]*int{}
+	weapIDs := make([]int, 0, len(guns))
+	for id := range guns {
+		weapIDs = append(weapIDs, int(id))
+	}
+	sort.Ints(weapIDs)
+
+	for _, id := range weapIDs {
+		weap := s.Lib.Weaps[WeapID(id)]
+		if guns[WeapID(id)] <= 0 || weap.Guidance == WeapGuidanceCarriedShip {
+			continue
+		}
+
+		w := &combatWeapon{weap: weap, count: guns[WeapID(id)]}
+		if ammoID, ok := weap.AmmoID(); ok {
+			if _, ok := pools[ammoID]; !ok {
+				n := ammo[ammoID]
+				pools[ammoID] = &n
+			}
+			w.ammo = pools[ammoID]
+		}
+
+		c.weapons = append(c.weapons, w)
+		c.reach = math.Max(c.reach, weapRange(weap))
+	}
+
+	return c, nil
+}
+
+func weapRange(w *Weap) float64 {
+	if isBeam(w) {
+		return float64(w.BeamLength)
+	}
+
+	return float64(w.Speed) / 100 * float64(w.Count)
+}
+
+func isBeam(w *Weap) bool {
+	return w.Guidance == WeapGuidanceBeam || w.Guidance == WeapGuidanceTurretBeam || w.Guidance == WeapGuidanceBeamPointDefence
+}
+
+// angleTo returns the angle of the vector, relative to the heading, between -pi and pi.
+func angleTo(heading, dx, dy float64) float64 {
+	return math.Remainder(math.Atan2(dy, dx)-heading, 2*math.Pi)
+}
+
+func (c *combatShip) ionized() bool {
+	return c.ionizeMax > 0 && c.ionization >= c.ionizeMax
+}
+
+func (c *combatShip) disabled() bool {
+	limit := 1.0 / 3
+	if c.ship.Flags.DisableAt10Percent {
+		limit = 0.1
+	}
+
+	return c.armour <= c.maxArmour*limit
+}
+
+// fly turns towards the target and closes to within weapons range of it.
+func (c *combatShip) fly(target *combatShip) {
+	speed, accel, turn := c.speed, c.accel, c.turn
+	if c.ionized() {
+		speed, accel, turn = speed/2, accel/2, turn/2
+	}
+
+	dx, dy := target.x-c.x, target.y-c.y
+	off := angleTo(c.heading, dx, dy)
+	c.heading += math.Max(-turn, math.Min(turn, off))
+
+	thrust := math.Hypot(dx, dy) > c.reach*0.8 && math.Abs(off) < math.Pi/2
+	if c.ship.Flags.Inertialess {
+		c.vx, c.vy = 0, 0
+		if thrust {
+			c.vx, c.vy = speed*math.Cos(c.heading), speed*math.Sin(c.heading)
+		}
+	} else if thrust {
+		c.vx += accel * math.Cos(c.heading)
+		c.vy += accel * math.Sin(c.heading)
+		if v := math.Hypot(c.vx, c.vy); v > speed {
+			c.vx, c.vy = c.vx*speed/v, c.vy*speed/v
+		}
+	} else if v := math.Hypot(c.vx, c.vy); v > 0 {
+		// Within range, brake to hold position rather than overshoot.
+		slower := math.Max(v-accel, 0)
+		c.vx, c.vy = c.vx*slower/v, c.vy*slower/v
+	}
+
+	c.x += c.vx
+	c.y += c.vy
+}
+
+// fire fires every weapon that's loaded and can bear on the target. Beams hit at once; everything else is returned
+// as shots in flight.
+func (c *combatShip) fire(side int, target *combatShip, rng *rand.Rand, dmg *CombatDamage) []*combatShot {
+	var shots []*combatShot
+
+	dx, dy := target.x-c.x, target.y-c.y
+	dist := math.Hypot(dx, dy)
+	off := angleTo(c.heading, dx, dy)
+
+	for _, w := range c.weapons {
+		if w.reload > 0 {
+			w.reload--
+			continue
+		}
+		if w.ammo != nil && *w.ammo <= 0 {
+			continue
+		}
+		if c.ionized() && w.weap.Seeker.NoFireIfIonized {
+			continue
+		}
+		if dist > weapRange(w.weap)+target.radius || !bears(w.weap, off) {
+			continue
+		}
+
+		// Several guns of a type either fire together or take turns, which shortens the reload.
+		volley := 1
+		reload := float64(w.weap.Reload)
+		if w.weap.Flags.MultipleOfTypeFire {
+			volley = w.count
+		} else {
+			reload /= float64(w.count)
+		}
+		if w.ammo != nil && volley > *w.ammo {
+			volley = *w.ammo
+		}
+
+		for i := 0; i < volley; i++ {
+			dmg.Shots++
+			if w.ammo != nil {
+				*w.ammo--
+			}
+
+			if isBeam(w.weap) {
+				target.hit(w.weap, dmg)
+				continue
+			}
+
+			aim := c.heading
+			if isTurret(w.weap) {
+				aim = math.Atan2(dy, dx)
+			}
+			aim += (rng.Float64()*2 - 1) * float64(w.weap.Inaccuracy) * math.Pi / 180
+
+			speed := float64(w.weap.Speed) / 100
+			shot := &combatShot{
+				side: side,
+				weap: w.weap,
+				x:    c.x,
+				y:    c.y,
+				vx:   speed * math.Cos(aim),
+				vy:   speed * math.Sin(aim),
+				life: int(w.weap.Count),
+			}
+			if w.weap.Guidance == WeapGuidanceFreeFallBomb {
+				shot.vx += c.vx * 0.8
+				shot.vy += c.vy * 0.8
+			}
+			shots = append(shots, shot)
+		}
+
+		w.reload = int(math.Ceil(reload))
+		w.burst++
+		if w.weap.BurstCount > 0 && w.burst >= int(w.weap.BurstCount) {
+			w.burst = 0
+			w.reload = int(w.weap.BurstReload)
+		}
+	}
+
+	return shots
+}
+
+func isTurret(w *Weap) bool {
+	switch w.Guidance {
+	case WeapGuidanceTurret, WeapGuidanceTurretBeam, WeapGuidanceTurretQuadrantFront, WeapGuidanceTurretQuadrantRear,
+		WeapGuidanceTurretPointDefence, WeapGuidanceBeamPointDefence:
+		return true
+	}
+
+	return false
+}
+
+// bears reports whether the weapon can fire at a target the given angle off the ship's nose.
+func bears(w *Weap, off float64) bool {
+	off = math.Abs(off)
+
+	switch w.Guidance {
+	case WeapGuidanceTurret, WeapGuidanceTurretBeam, WeapGuidanceTurretPointDefence, WeapGuidanceBeamPointDefence:
+		return true
+	case WeapGuidanceTurretQuadrantFront:
+		return off <= math.Pi/4
+	case WeapGuidanceTurretQuadrantRear:
+		return off >= math.Pi*3/4
+	case WeapGuidanceHoming:
+		return off <= math.Pi/2
+	}
+
+	// Fixed guns wait until the target is close to dead ahead.
+	return off <= math.Max(float64(w.Inaccuracy), 5)*math.Pi/180
+}
+
+// hit applies one shot's damage to the ship.
+func (c *combatShip) hit(w *Weap, dmg *CombatDamage) {
+	dmg.Hits++
+
+	mass, energy := float64(w.MassDmg), float64(w.EnergyDmg)
+
+	// Whatever part of the shot the shields can't absorb goes through to the armour. Only the damage the ship could
+	// absorb counts, so the totals aren't inflated by overkill.
+	through := 1.0
+	if !w.Flags.IgnoreShields && c.shield > 0 {
+		d := energy + mass/2
+		if d > c.shield {
+			through = (d - c.shield) / d
+			d = c.shield
+		} else {
+			through = 0
+		}
+		c.shield -= d
+		dmg.Shield += d
+		dmg.ByWeap[w.ID] += d
+	}
+
+	if through > 0 {
+		d := math.Min((mass+energy/2)*through, c.armour)
+		c.armour -= d
+		dmg.Armour += d
+		dmg.ByWeap[w.ID] += d
+	}
+
+	if w.Ionization > 0 {
+		c.ionization += float64(w.Ionization)
+		dmg.Ionization += float64(w.Ionization)
+	}
+}
+
+func (c *combatShip) recharge() {
+	c.shield = math.Min(c.shield+c.shieldRech, c.maxShield)
+	c.armour = math.Min(c.armour+c.armourRech, c.maxArmour)
+	c.ionization = math.Max(c.ionization-c.deionize, 0)
+}
+
+type combatShot struct {
+	side int
+	weap *Weap
+
+	x, y, vx, vy float64
+	life         int
+}
+
+// move advances the shot a frame, steering homing shots towards the target, and reports whether it hit.
+func (s *combatShot) move(target *combatShip) bool {
+	if s.weap.Guidance == WeapGuidanceHoming {
+		turn := float64(s.weap.GuidedTurn)
+		if turn <= 0 {
+			turn = combatDefaultTurn
+		}
+		turn *= math.Pi / 180
+
+		heading := math.Atan2(s.vy, s.vx)
+		off := angleTo(heading, target.x-s.x, target.y-s.y)
+		heading += math.Max(-turn, math.Min(turn, off))
+
+		speed := math.Hypot(s.vx, s.vy)
+		s.vx, s.vy = speed*math.Cos(heading), speed*math.Sin(heading)
+	}
+
+	s.x += s.vx
+	s.y += s.vy
+	s.life--
+
+	return math.Hypot(target.x-s.x, target.y-s.y) <= target.radius+float64(s.weap.ProxRadius)
+}
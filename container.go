@@ -0,0 +1,193 @@
+package resources
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Scenario files reach us in several packagings, since most file systems have no resource forks:
+//
+//  MacBinary II/III  A 128 byte header followed by the data and resource forks, each padded to 128 bytes.
+//  AppleSingle       A header with an entry table pointing at the forks, usually named .as.
+//  AppleDouble       The same format without the data fork, stored next to the file as ._name.
+//  .rsrc             The raw resource fork, as written by most unarchivers.
+//  ..namedfork/rsrc  The resource fork itself, on macOS.
+//  .rez              The Windows port's archive format.
+
+var ErrNoResourceFork = errors.New("no resource fork found")
+
+const (
+	macBinaryHeaderLen     = 128
+	appleSingleMagic       = 0x00051600
+	appleDoubleMagic       = 0x00051607
+	appleEntryResourceFork = 2
+)
+
+// crc16XModem is the CRC-16/CCITT variant MacBinary II and III use to check their header.
+func crc16XModem(b []byte) uint16 {
+	var crc uint16
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+func padTo128(n uint32) uint32 {
+	return (n + 127) &^ 127
+}
+
+// isMacBinary checks the fields every MacBinary version requires to be zero or in range.
+func isMacBinary(b []byte) bool {
+	if len(b) < macBinaryHeaderLen || b[0] != 0 || b[74] != 0 || b[82] != 0 {
+		return false
+	}
+	if b[1] < 1 || b[1] > 63 {
+		return false
+	}
+
+	be := binary.BigEndian
+	dataLen, rsrcLen := be.Uint32(b[83:]), be.Uint32(b[87:])
+
+	return uint64(macBinaryHeaderLen)+uint64(padTo128(dataLen))+uint64(rsrcLen) <= uint64(len(b))
+}
+
+// macBinaryResourceFork extracts the resource fork, checking the header CRC for MacBinary II and later.
+func macBinaryResourceFork(b []byte) ([]byte, error) {
+	be := binary.BigEndian
+
+	if b[122] >= 129 || string(b[102:106]) == "mBIN" {
+		if want, got := be.Uint16(b[124:]), crc16XModem(b[:124]); want != got {
+			return nil, fmt.Errorf("MacBinary header CRC is %#04x, expected %#04x", got, want)
+		}
+	}
+
+	dataLen, rsrcLen := be.Uint32(b[83:]), be.Uint32(b[87:])
+	secondary := uint32(be.Uint16(b[120:]))
+
+	start := macBinaryHeaderLen + padTo128(secondary) + padTo128(dataLen)
+	if uint64(start)+uint64(rsrcLen) > uint64(len(b)) {
+		return nil, errors.New("MacBinary resource fork runs past the end of the file")
+	}
+	if rsrcLen == 0 {
+		return nil, ErrNoResourceFork
+	}
+
+	return b[start : start+rsrcLen], nil
+}
+
+func isAppleSingleOrDouble(b []byte) bool {
+	if len(b) < 26 {
+		return false
+	}
+
+	magic := binary.BigEndian.Uint32(b)
+	return magic == appleSingleMagic || magic == appleDoubleMagic
+}
+
+func appleDoubleResourceFork(b []byte) ([]byte, error) {
+	be := binary.BigEndian
+	n := int(be.Uint16(b[24:]))
+	if 26+n*12 > len(b) {
+		return nil, errors.New("AppleDouble entry table runs past the end of the file")
+	}
+
+	for i := 0; i < n; i++ {
+		e := b[26+i*12:]
+		if be.Uint32(e) != appleEntryResourceFork {
+			continue
+		}
+
+		offset, length := uint64(be.Uint32(e[4:])), uint64(be.Uint32(e[8:]))
+		if offset+length > uint64(len(b)) {
+			return nil, errors.New("AppleDouble resource fork runs past the end of the file")
+		}
+		if length == 0 {
+			return nil, ErrNoResourceFork
+		}
+
+		return b[offset : offset+length], nil
+	}
+
+	return nil, ErrNoResourceFork
+}
+
+// ReadContainerFromBytes unwraps a MacBinary, AppleSingle or AppleDouble file, then reads the resource fork or .rez
+// archive inside. Anything else is assumed to be a bare resource fork.
+func ReadContainerFromBytes(b []byte) (*ResourceLibrary, error) {
+	var err error
+
+	switch {
+	case IsRez(b):
+	case isAppleSingleOrDouble(b):
+		b, err = appleDoubleResourceFork(b)
+	case isMacBinary(b):
+		b, err = macBinaryResourceFork(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rf, err := ReadResourcesFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadResourceLibrary(context.Background(), rf, nil)
+}
+
+// sidecarPaths lists the places a file's resource fork may have been put when it was copied off a Mac.
+func sidecarPaths(path string) []string {
+	dir, name := filepath.Split(path)
+
+	return []string{
+		filepath.Join(path, "..namedfork", "rsrc"),
+		filepath.Join(dir, "._"+name),
+		path + ".rsrc",
+	}
+}
+
+// OpenScenario loads a scenario or plugin from any of the supported packagings. If the file itself holds no
+// resources (e.g. it's the empty data fork of a Mac file), its sidecar resource forks are tried in turn.
+func OpenScenario(path string) (*ResourceLibrary, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	openErr := ErrNoResourceFork
+	if len(b) > 0 {
+		lib, err := ReadContainerFromBytes(b)
+		if err == nil {
+			return lib, nil
+		}
+		openErr = err
+	}
+
+	for _, sidecar := range sidecarPaths(path) {
+		b, err := ioutil.ReadFile(sidecar)
+		if err != nil || len(b) == 0 {
+			continue
+		}
+
+		lib, err := ReadContainerFromBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sidecar, err)
+		}
+
+		return lib, nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", path, openErr)
+}
@@ -47,7 +47,7 @@ type Cron struct {
 	Contribute FlagMask64 // When the cron event is active, these two Contribute fields together form a 64-bit flag that is subsequently combined with the Contribute fields from the player's ship and the other outfit items in the player's possession, to be used with the Require fields in the outf and misn resources.
 	Require    FlagMask64 // These two Require fields together form a 64-bit flag that is logically and'ed with the Contribute fields from the player's current ship and outfit items. Unless for each 1 bit in the Require fields there is a matching 1 bit in one or more of the Contribute fields, the cron will not be activated. Leave these set to zero if unused.
 
-	NewsGovt    [4]StrAID // On planets or stations that are allied with the government whose ID is given by one of the NewsGovt fields, a string will be randomly selected from the STR# resource whose ID is given by the corresponding GovtNewsStr field, and will be displayed as news while the cron event is active. This allows you to let up to four different governments (and their allies) have their own "local news" for a given cron event. Set unused NewsGovt and GovtNewsStr fields to -1.
+	NewsGovt    [4]GovtID // On planets or stations that are allied with the government whose ID is given by one of the NewsGovt fields, a string will be randomly selected from the STR# resource whose ID is given by the corresponding GovtNewsStr field, and will be displayed as news while the cron event is active. This allows you to let up to four different governments (and their allies) have their own "local news" for a given cron event. Set unused NewsGovt and GovtNewsStr fields to -1.
 	GovtNewsStr [4]StrAID
 
 	IndNewsStr StrAID // The ID of a STR# resource from which to randomly select a string to be displayed in the news dialog while this cron event is in progress, if it doesn't have any applicable local news. Set to -1 for no independent news.
@@ -81,11 +81,11 @@ func CronFromBytes(id CronID, b []byte) *Cron {
 		OnEnd:      ControlBitFunction(byteString(b[534:], 255)),
 		Contribute: FlagMask64(binary.BigEndian.Uint64(b[790:])),
 		Require:    FlagMask64(binary.BigEndian.Uint64(b[798:])),
-		NewsGovt: [4]StrAID{
-			StrAID(binary.BigEndian.Uint16(b[806:])),
-			StrAID(binary.BigEndian.Uint16(b[808:])),
-			StrAID(binary.BigEndian.Uint16(b[810:])),
-			StrAID(binary.BigEndian.Uint16(b[812:])),
+		NewsGovt: [4]GovtID{
+			GovtID(binary.BigEndian.Uint16(b[806:])),
+			GovtID(binary.BigEndian.Uint16(b[808:])),
+			GovtID(binary.BigEndian.Uint16(b[810:])),
+			GovtID(binary.BigEndian.Uint16(b[812:])),
 		},
 		GovtNewsStr: [4]StrAID{
 			StrAID(binary.BigEndian.Uint16(b[814:])),
@@ -16,7 +16,9 @@ import (
 
 type DudeID IDType
 
-type DudeFlags struct {
+// DudeBooty holds the Booty flags: what can be plundered from ships of the dude class. The same field also carries
+// the NoHitBoxForPlayer flag, which has nothing to do with booty.
+type DudeBooty struct {
 	CarriesFood            bool // 0x0001 Carries food when plundered.
 	CarriesIndustrialGoods bool // 0x0002 Carries industrial goods.
 	CarriesMedicalSupplies bool // 0x0004 Carries medical supplies.
@@ -24,9 +26,35 @@ type DudeFlags struct {
 	CarriesMetal           bool // 0x0010 Carries metal.
 	CarriesEquipment       bool // 0x0020 Carries equipment.
 	CarriesMoney           bool // 0x0040 Carries money (amount depends on the ship's purchase price).
+	CarriesAmmo            bool // 0x0080 Carries ammunition for the boarding ship's weapons.
 	NoHitBoxForPlayer      bool // 0x0100 Ships of this dude type can't be hit by the player and their shots can't hit the player (useful for things like AuxShip mission escorts, etc.).
 }
 
+// Commodities returns the commodities ships of the dude class carry, in CommodityType order.
+func (b DudeBooty) Commodities() []CommodityType {
+	var out []CommodityType
+	for i, carried := range []bool{
+		b.CarriesFood,
+		b.CarriesIndustrialGoods,
+		b.CarriesMedicalSupplies,
+		b.CarriesLuxuryGoods,
+		b.CarriesMetal,
+		b.CarriesEquipment,
+	} {
+		if carried {
+			out = append(out, CommodityTypeFood+CommodityType(i))
+		}
+	}
+
+	return out
+}
+
+// Boardable reports whether there's anything to plunder at all. Without any booty the player is "repelled while
+// attempting to board".
+func (b DudeBooty) Boardable() bool {
+	return len(b.Commodities()) > 0 || b.CarriesMoney || b.CarriesAmmo
+}
+
 type DudeInfoTypes struct {
 	GoodsPrices    bool    // 0x1000 Good prices.
 	DisasterInfo   bool    // 0x2000 Disaster info.
@@ -36,15 +64,14 @@ type DudeInfoTypes struct {
 
 // You can set different combinations of booty to be had from ships of a certain dude class by ORing different bits
 // into the dude's Booty field. If a dude class has a booty flag of 0x0000, then you can't get anything from the ship,
-// and you're told that you were "repelled while attempting to board" it. The different booty flags are documented above
+// and you're told that you were "repelled while attempting to board" it. The different booty flags are documented above.
 
 type Dude struct {
 	ID DudeID
 
-	AIType      AIType     // Which type of AI to use for ships of this dude class (see below). If you set this to 0, each ship will use its own inherent AI type.
-	Govt        GovtID     // The ID number of the dude class's government, or -1 for independent.
-	Booty       FlagMask32 // Flags that define what you'll get when you board a ship of this dude class.
-	Flags       DudeFlags
+	AIType      AIType        // Which type of AI to use for ships of this dude class (see below). If you set this to 0, each ship will use its own inherent AI type.
+	Govt        GovtID        // The ID number of the dude class's government, or -1 for independent.
+	Booty       DudeBooty     // What you'll get when you board a ship of this dude class.
 	InfoTypes   DudeInfoTypes // What kind of info to display when hailed.
 	ShipType    [16]ShipID    // These fields contain the ID numbers of up to 16 different ship classes. Set to 0 or -1 if unused.
 	Probability [16]int16     // These fields set the probability that a ship of this dude class will be of a certain ship type.
@@ -56,13 +83,13 @@ func DudeFromResource(resource resourcefork.Resource) *Dude {
 
 func DudeFromBytes(id DudeID, b []byte) *Dude {
 	flags := binary.BigEndian.Uint16(b[4:])
-	flags2 := binary.BigEndian.Uint16(b[4:])
+	flags2 := binary.BigEndian.Uint16(b[6:])
 
 	t := &Dude{
 		ID:     id,
 		AIType: AIType(binary.BigEndian.Uint16(b[0:])),
 		Govt:   GovtID(binary.BigEndian.Uint16(b[2:])),
-		Flags: DudeFlags{
+		Booty: DudeBooty{
 			CarriesFood:            flags&0x0001 == 0x0001,
 			CarriesIndustrialGoods: flags&0x0002 == 0x0002,
 			CarriesMedicalSupplies: flags&0x0004 == 0x0004,
@@ -70,6 +97,7 @@ func DudeFromBytes(id DudeID, b []byte) *Dude {
 			CarriesMetal:           flags&0x0010 == 0x0010,
 			CarriesEquipment:       flags&0x0020 == 0x0020,
 			CarriesMoney:           flags&0x0040 == 0x0040,
+			CarriesAmmo:            flags&0x0080 == 0x0080,
 			NoHitBoxForPlayer:      flags&0x0100 == 0x0100,
 		},
 		InfoTypes: DudeInfoTypes{
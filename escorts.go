@@ -0,0 +1,164 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/imle/resourcefork"
+)
+
+// A pilot's escorts and fighters are stored as ship indices, the shïp ID - 128, in fixed slots:
+//
+//  EscortClass   -1 for an empty slot, 0-767 for a captured escort, or 1000-1767 for a hired one.
+//  FighterClass  -1 for an empty slot, or 0-767 for a fighter.
+//
+// Fighters belong to the carrier bays, wëaps with WeapGuidanceCarriedShip, whose AmmoType is their shïp ID.
+//
+// Hired escorts cost EscortHireFraction of their ship's Cost up front, and EscortDailyFraction of it every day after.
+// Captured escorts are free.
+
+const (
+	EscortHireFraction  = 0.1
+	EscortDailyFraction = 0.001
+
+	escortHiredOffset = 1000
+	escortMaxIndex    = 767
+
+	// StrAIDCombatRatings holds the names of the combat ratings, from the lowest up.
+	StrAIDCombatRatings StrAID = 138
+)
+
+// CombatRatingThresholds are the lowest combat ratings for each string of StrAIDCombatRatings.
+var CombatRatingThresholds = []int16{0, 1, 100, 200, 400, 800, 1600, 3200, 6400, 12800, 25600}
+
+type Escort struct {
+	Slot  int
+	Ship  *Ship
+	Hired bool
+}
+
+// HireCost returns what the escort cost up front, or nothing if it was captured.
+func (e Escort) HireCost() Credits {
+	if !e.Hired {
+		return 0
+	}
+
+	return Credits(math.Round(float64(e.Ship.Cost) * EscortHireFraction))
+}
+
+// decodePilotShip returns the shïp ID and whether it's hired for an escort or fighter slot.
+func decodePilotShip(v ShipID) (ShipID, bool, bool) {
+	switch {
+	case v >= 0 && v <= escortMaxIndex:
+		return v + ShipID(resourcefork.ResourceForkIDOffset), false, true
+	case v >= escortHiredOffset && v <= escortHiredOffset+escortMaxIndex:
+		return v - escortHiredOffset + ShipID(resourcefork.ResourceForkIDOffset), true, true
+	}
+
+	return 0, false, false
+}
+
+// Escorts returns the pilot's escorts in slot order.
+func (p *NpiL) Escorts(lib *ResourceLibrary) ([]Escort, error) {
+	var out []Escort
+	for i, v := range p.EscortClass {
+		id, hired, ok := decodePilotShip(v)
+		if !ok {
+			continue
+		}
+
+		ship, ok := lib.Ships[id]
+		if !ok {
+			return nil, fmt.Errorf("escort slot %d: unknown shïp %d", i, id)
+		}
+		out = append(out, Escort{Slot: i, Ship: ship, Hired: hired})
+	}
+
+	return out, nil
+}
+
+// FighterBay is a carrier weapon and the pilot's fighters that launch from it.
+type FighterBay struct {
+	Weap     *Weap // Nil for fighters that no bay the pilot carries launches.
+	Fighters []*Ship
+}
+
+// Fighters returns the pilot's fighters grouped by the carrier bay they belong to, in bay ID order, with any fighters
+// without a bay last.
+func (p *NpiL) Fighters(lib *ResourceLibrary) ([]FighterBay, error) {
+	var bays []FighterBay
+	byShip := map[ShipID]int{}
+	for i, n := range p.WeaponCount {
+		w, ok := lib.Weaps[WeapID(i+resourcefork.ResourceForkIDOffset)]
+		if !ok || n <= 0 || w.Guidance != WeapGuidanceCarriedShip {
+			continue
+		}
+
+		if _, ok := byShip[ShipID(w.AmmoType)]; !ok {
+			byShip[ShipID(w.AmmoType)] = len(bays)
+		}
+		bays = append(bays, FighterBay{Weap: w})
+	}
+
+	var loose []*Ship
+	for i, v := range p.FighterClass {
+		id, hired, ok := decodePilotShip(v)
+		if !ok || hired {
+			continue
+		}
+
+		ship, ok := lib.Ships[id]
+		if !ok {
+			return nil, fmt.Errorf("fighter slot %d: unknown shïp %d", i, id)
+		}
+
+		if b, ok := byShip[id]; ok {
+			bays[b].Fighters = append(bays[b].Fighters, ship)
+		} else {
+			loose = append(loose, ship)
+		}
+	}
+
+	if len(loose) > 0 {
+		bays = append(bays, FighterBay{Fighters: loose})
+	}
+
+	return bays, nil
+}
+
+// CombatRatingLabel returns the name of the pilot's combat rating from the scenario's STR# resource, or an empty
+// string if there isn't one.
+func (p *NpiL) CombatRatingLabel(lib *ResourceLibrary) string {
+	names, ok := lib.StrAs[StrAIDCombatRatings]
+	if !ok || len(names.Values) == 0 {
+		return ""
+	}
+
+	i := sort.Search(len(CombatRatingThresholds), func(i int) bool { return CombatRatingThresholds[i] > p.CombatRating })
+	if i > len(names.Values) {
+		i = len(names.Values)
+	}
+	if i < 1 {
+		i = 1
+	}
+
+	return names.Get(i)
+}
+
+// EscortUpkeep returns what the pilot's hired escorts cost each day.
+func (p *NpiL) EscortUpkeep(lib *ResourceLibrary) (Credits, error) {
+	escorts, err := p.Escorts(lib)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	for _, e := range escorts {
+		if e.Hired {
+			total += float64(e.Ship.Cost) * EscortDailyFraction
+		}
+	}
+
+	return Credits(math.Round(total)), nil
+}
@@ -15,8 +15,6 @@ type FletFlags struct {
 	FreightersHaveRandomCargo bool // 0x0001 Freighters (InherentAI <= 2) in this fleet will have random cargo when boarded.
 }
 
-type LinkSystID SystID
-
 type Flet struct {
 	ID FletID
 
@@ -32,7 +30,7 @@ type Flet struct {
 	// 15000-15255 Any system belonging to an ally of this govt.
 	// 20000-20255 Any system belonging to any but this govt.
 	// 25000-25255 Any system belonging to an enemy of this govt.
-	LinkSyst LinkSystID // Which systems the fleet can be created in.
+	LinkSyst SystemSelector // Which systems the fleet can be created in.
 
 	AppearOn ControlBitTest // A control bit test field that will cause a given fleet to appear only when the expression evaluates to true. If this field is left blank it will be ignored.
 
@@ -70,7 +68,7 @@ func FletFromBytes(id FletID, b []byte) *Flet {
 			int16(binary.BigEndian.Uint16(b[24:])),
 		},
 		Govt:     GovtID(binary.BigEndian.Uint16(b[26:])),
-		LinkSyst: LinkSystID(binary.BigEndian.Uint16(b[28:])),
+		LinkSyst: ParseSystemSelector(int16(binary.BigEndian.Uint16(b[28:]))),
 		AppearOn: ControlBitTest(byteString(b[30:], 254)),
 		Quote:    StrAID(binary.BigEndian.Uint16(b[286:])),
 		Flags: FletFlags{
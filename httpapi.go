@@ -0,0 +1,339 @@
+package resources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The HTTP API serves a loaded library read-only:
+//
+//  GET /ships                        Every ship, see below for filtering and pagination.
+//  GET /ships/{id}                   One ship.
+//  GET /systs/{id}/neighbors         The systems one jump away, as a list.
+//  GET /route?from={id}&to={id}      The shortest chain of jumps between two systems.
+//  GET /images/pict/{id}.png         A PICT as a PNG.
+//  GET /spins/{id}/frames/{n}.png    One frame of a spïn's sprites as a PNG, counting from 0.
+//
+// Lists take offset and limit parameters and are returned as {"total", "offset", "limit", "items"}. Any other
+// parameter filters the list on the top-level JSON field of that name, ignoring case: /ships?Cargo=20. Every response
+// carries an ETag, and requests with a matching If-None-Match get 304 Not Modified.
+
+const (
+	httpDefaultLimit = 50
+	httpMaxLimit     = 500
+)
+
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func httpErrorf(status int, format string, args ...interface{}) *httpError {
+	return &httpError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+type httpList struct {
+	Total  int           `json:"total"`
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+	Items  []interface{} `json:"items"`
+}
+
+type httpRoute struct {
+	From  SystID   `json:"from"`
+	To    SystID   `json:"to"`
+	Jumps int      `json:"jumps"`
+	Systs []SystID `json:"systs"`
+}
+
+type httpHandler struct {
+	lib *ResourceLibrary
+}
+
+// NewHTTPHandler returns a handler serving the library's data. The library must not be changed while it's in use.
+func NewHTTPHandler(lib *ResourceLibrary) http.Handler {
+	return &httpHandler{lib: lib}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.writeError(w, httpErrorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		return
+	}
+
+	body, contentType, err := h.route(r)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+// route picks the endpoint by splitting the path into segments.
+func (h *httpHandler) route(r *http.Request) ([]byte, string, error) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case len(parts) == 1 && parts[0] == "ships":
+		items := make([]interface{}, 0, len(h.lib.Ships))
+		for _, id := range sortedShipIDs(h.lib.Ships) {
+			items = append(items, h.lib.Ships[id])
+		}
+		return h.list(items, query)
+
+	case len(parts) == 2 && parts[0] == "ships":
+		id, err := pathID(parts[1])
+		if err != nil {
+			return nil, "", err
+		}
+		ship, ok := h.lib.Ships[ShipID(id)]
+		if !ok {
+			return nil, "", httpErrorf(http.StatusNotFound, "no shïp %d", id)
+		}
+		return h.json(ship)
+
+	case len(parts) == 3 && parts[0] == "systs" && parts[2] == "neighbors":
+		id, err := pathID(parts[1])
+		if err != nil {
+			return nil, "", err
+		}
+		if _, ok := h.lib.Systs[SystID(id)]; !ok {
+			return nil, "", httpErrorf(http.StatusNotFound, "no sÿst %d", id)
+		}
+
+		var items []interface{}
+		for _, n := range h.lib.Neighbors(SystID(id)) {
+			items = append(items, h.lib.Systs[n])
+		}
+		return h.list(items, query)
+
+	case len(parts) == 1 && parts[0] == "route":
+		from, err := queryID(query, "from")
+		if err != nil {
+			return nil, "", err
+		}
+		to, err := queryID(query, "to")
+		if err != nil {
+			return nil, "", err
+		}
+
+		route := h.lib.Route(SystID(from), SystID(to))
+		if route == nil {
+			return nil, "", httpErrorf(http.StatusNotFound, "no route from sÿst %d to sÿst %d", from, to)
+		}
+		return h.json(httpRoute{From: SystID(from), To: SystID(to), Jumps: len(route) - 1, Systs: route})
+
+	case len(parts) == 3 && parts[0] == "images" && parts[1] == "pict":
+		id, err := pathID(strings.TrimSuffix(parts[2], ".png"))
+		if err != nil || !strings.HasSuffix(parts[2], ".png") {
+			return nil, "", httpErrorf(http.StatusNotFound, "no such image %q", parts[2])
+		}
+		if _, ok := h.lib.Picts[PictID(id)]; !ok {
+			return nil, "", httpErrorf(http.StatusNotFound, "no PICT %d", id)
+		}
+		pict, err := h.lib.Pict(PictID(id))
+		if err != nil {
+			return nil, "", err
+		}
+		return h.png(pict.Image)
+
+	case len(parts) == 4 && parts[0] == "spins" && parts[2] == "frames":
+		id, err := pathID(parts[1])
+		if err != nil {
+			return nil, "", err
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(parts[3], ".png"))
+		if err != nil || !strings.HasSuffix(parts[3], ".png") {
+			return nil, "", httpErrorf(http.StatusNotFound, "no such frame %q", parts[3])
+		}
+		spin, ok := h.lib.Spins[SpinID(id)]
+		if !ok {
+			return nil, "", httpErrorf(http.StatusNotFound, "no spïn %d", id)
+		}
+		frames, err := spin.Frames(h.lib)
+		if err != nil {
+			return nil, "", err
+		}
+		if n < 0 || n >= len(frames) {
+			return nil, "", httpErrorf(http.StatusNotFound, "spïn %d has %d frames", id, len(frames))
+		}
+		return h.png(frames[n])
+	}
+
+	return nil, "", httpErrorf(http.StatusNotFound, "no such endpoint %s", r.URL.Path)
+}
+
+// list filters and pages the items, which must already be in a stable order.
+func (h *httpHandler) list(items []interface{}, query map[string][]string) ([]byte, string, error) {
+	out := httpList{Limit: httpDefaultLimit, Items: []interface{}{}}
+
+	filters := map[string]string{}
+	for k, v := range query {
+		var err error
+		switch strings.ToLower(k) {
+		case "offset":
+			out.Offset, err = strconv.Atoi(v[0])
+		case "limit":
+			out.Limit, err = strconv.Atoi(v[0])
+		default:
+			filters[strings.ToLower(k)] = v[0]
+		}
+		if err != nil || out.Offset < 0 || out.Limit < 0 {
+			return nil, "", httpErrorf(http.StatusBadRequest, "invalid %s %q", k, v[0])
+		}
+	}
+	if out.Limit > httpMaxLimit {
+		out.Limit = httpMaxLimit
+	}
+
+	for _, item := range items {
+		ok, err := matchesFilters(item, filters)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ok {
+			continue
+		}
+
+		if out.Total >= out.Offset && len(out.Items) < out.Limit {
+			out.Items = append(out.Items, item)
+		}
+		out.Total++
+	}
+
+	return h.json(out)
+}
+
+// matchesFilters compares each filter against the item's JSON field of the same name, as the field's JSON text with
+// any quotes removed.
+func matchesFilters(item interface{}, filters map[string]string) (bool, error) {
+	if len(filters) == 0 {
+		return true, nil
+	}
+
+	b, err := json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return false, err
+	}
+
+	lower := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		lower[strings.ToLower(k)] = v
+	}
+
+	for k, want := range filters {
+		raw, ok := lower[k]
+		if !ok {
+			return false, httpErrorf(http.StatusBadRequest, "unknown field %q", k)
+		}
+
+		got := string(raw)
+		if s := ""; json.Unmarshal(raw, &s) == nil {
+			got = s
+		}
+		if got != want {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (h *httpHandler) json(v interface{}) ([]byte, string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return append(b, '\n'), "application/json", nil
+}
+
+func (h *httpHandler) png(img image.Image) ([]byte, string, error) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), "image/png", nil
+}
+
+func (h *httpHandler) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if e, ok := err.(*httpError); ok {
+		status = e.status
+	}
+
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(b, '\n'))
+}
+
+func pathID(s string) (IDType, error) {
+	id, err := strconv.ParseInt(s, 10, 16)
+	if err != nil {
+		return 0, httpErrorf(http.StatusNotFound, "invalid ID %q", s)
+	}
+
+	return IDType(id), nil
+}
+
+func queryID(query map[string][]string, key string) (IDType, error) {
+	v, ok := query[key]
+	if !ok {
+		return 0, httpErrorf(http.StatusBadRequest, "missing %s", key)
+	}
+
+	id, err := strconv.ParseInt(v[0], 10, 16)
+	if err != nil {
+		return 0, httpErrorf(http.StatusBadRequest, "invalid %s %q", key, v[0])
+	}
+
+	return IDType(id), nil
+}
+
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == etag || v == "*" {
+			return true
+		}
+	}
+
+	return false
+}
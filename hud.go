@@ -0,0 +1,188 @@
+package resources
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+)
+
+// A HUD preview draws an ïntf's status bar with sample data so that the areas can be checked against the background
+// PICT. Bars are filled from the left, the radar gets random blips around the player at its centre, and the text
+// areas get greeked lines, one bright heading followed by dim lines, at the interface's font size.
+//
+// Nova falls back to PICT 128 when StatusBkgnd is below 128, but in most scenarios that's the splash screen rather
+// than a status bar, so the preview only draws a StatusBkgnd of 128 or more. Otherwise, or if the PICT is missing, the
+// areas are drawn on black.
+//
+// Nova stores the ïntf colours as 0x00RRGGBB, so the alpha byte is ignored and every colour is drawn opaque.
+
+type HUDPreview struct {
+	Lib *ResourceLibrary
+
+	Shield float64 // Fraction of the shield bar to fill, from 0 to 1.
+	Armor  float64 // Fraction of the armour bar to fill, from 0 to 1.
+	Fuel   float64 // Fraction of the fuel bar to fill, from 0 to 1.
+	Jumps  int     // Jumps the full fuel bar holds, used to split it into full and partial portions.
+	Blips  int     // Number of radar blips besides the player.
+
+	// Outline, if not nil, is drawn around every area.
+	Outline color.Color
+}
+
+func NewHUDPreview(lib *ResourceLibrary) *HUDPreview {
+	return &HUDPreview{
+		Lib:    lib,
+		Shield: 0.75,
+		Armor:  0.5,
+		Fuel:   0.6,
+		Jumps:  3,
+		Blips:  8,
+	}
+}
+
+// Render returns the preview of the interface. The seed picks the radar blips and the lengths of the text lines.
+func (h *HUDPreview) Render(id IntfID, seed int64) (*image.NRGBA, error) {
+	intf, ok := h.Lib.Intfs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown ïntf %d", id)
+	}
+
+	areas := []image.Rectangle{
+		intf.RadarArea, intf.ShieldArea, intf.ArmorArea, intf.FuelArea,
+		intf.NavArea, intf.WeapArea, intf.TargArea, intf.CargoArea,
+	}
+
+	bkgnd := intf.StatusBkgnd
+
+	var dst *image.NRGBA
+	if _, ok := h.Lib.Picts[bkgnd]; ok && bkgnd >= 128 {
+		pict, err := h.Lib.Pict(bkgnd)
+		if err != nil {
+			return nil, err
+		}
+		b := pict.Image.Bounds()
+		dst = image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(dst, dst.Bounds(), pict.Image, b.Min, draw.Src)
+	} else {
+		var bounds image.Rectangle
+		for _, r := range areas {
+			bounds = bounds.Union(r.Canon())
+		}
+		dst = image.NewNRGBA(image.Rect(0, 0, bounds.Max.X, bounds.Max.Y))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+
+	h.radar(dst, intf, rnd)
+	hudBar(dst, intf.ShieldArea.Canon(), 0, h.Shield, intf.ShieldColor)
+	hudBar(dst, intf.ArmorArea.Canon(), 0, h.Armor, intf.ArmorColor)
+
+	fuel := clamp01(h.Fuel)
+	full := fuel
+	if h.Jumps > 0 {
+		full = math.Floor(fuel*float64(h.Jumps)) / float64(h.Jumps)
+	}
+	hudBar(dst, intf.FuelArea.Canon(), 0, full, intf.FuelFull)
+	hudBar(dst, intf.FuelArea.Canon(), full, fuel, intf.FuelPartial)
+
+	for _, r := range []image.Rectangle{intf.NavArea, intf.WeapArea, intf.TargArea, intf.CargoArea} {
+		h.text(dst, intf, r.Canon(), rnd)
+	}
+
+	if h.Outline != nil {
+		for _, r := range areas {
+			hudOutline(dst, r.Canon(), h.Outline)
+		}
+	}
+
+	return dst, nil
+}
+
+func (h *HUDPreview) radar(dst draw.Image, intf *Intf, rnd *rand.Rand) {
+	r := intf.RadarArea.Canon()
+	if r.Empty() {
+		return
+	}
+
+	bright, dim := opaque(intf.BrightRadar), opaque(intf.DimRadar)
+	for i := 0; i < h.Blips; i++ {
+		p := image.Pt(r.Min.X+rnd.Intn(r.Dx()), r.Min.Y+rnd.Intn(r.Dy()))
+		c := dim
+		if i%2 == 0 {
+			c = bright
+		}
+		draw.Draw(dst, image.Rectangle{Min: p, Max: p.Add(image.Pt(2, 2))}.Intersect(r), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	center := image.Pt(r.Min.X+r.Dx()/2, r.Min.Y+r.Dy()/2)
+	draw.Draw(dst, image.Rect(center.X-1, center.Y-1, center.X+2, center.Y+2).Intersect(r), image.NewUniform(bright), image.Point{}, draw.Src)
+}
+
+// text fills the area with greeked lines, each as tall as two thirds of the font size.
+func (h *HUDPreview) text(dst draw.Image, intf *Intf, r image.Rectangle, rnd *rand.Rand) {
+	if r.Empty() {
+		return
+	}
+
+	size := int(intf.StatFontSize)
+	if size <= 0 {
+		size = 9
+	}
+	glyph := size * 2 / 3
+	if glyph < 1 {
+		glyph = 1
+	}
+
+	bright, dim := opaque(intf.BrightText), opaque(intf.DimText)
+	for i, y := 0, r.Min.Y+2; y+size <= r.Max.Y; i, y = i+1, y+size+2 {
+		c := dim
+		if i == 0 {
+			c = bright
+		}
+
+		width := r.Dx() * (40 + rnd.Intn(50)) / 100
+		line := image.Rect(r.Min.X+2, y+size-glyph, r.Min.X+2+width, y+size).Intersect(r)
+		draw.Draw(dst, line, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
+
+// hudBar fills the area from fraction from to fraction to of its width.
+func hudBar(dst draw.Image, r image.Rectangle, from, to float64, c color.Color) {
+	if r.Empty() {
+		return
+	}
+
+	x0 := r.Min.X + int(math.Round(float64(r.Dx())*clamp01(from)))
+	x1 := r.Min.X + int(math.Round(float64(r.Dx())*clamp01(to)))
+	draw.Draw(dst, image.Rect(x0, r.Min.Y, x1, r.Max.Y), image.NewUniform(opaque(c)), image.Point{}, draw.Src)
+}
+
+func hudOutline(dst draw.Image, r image.Rectangle, c color.Color) {
+	if r.Empty() {
+		return
+	}
+
+	u := image.NewUniform(c)
+	draw.Draw(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), u, image.Point{}, draw.Over)
+	draw.Draw(dst, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), u, image.Point{}, draw.Over)
+	draw.Draw(dst, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), u, image.Point{}, draw.Over)
+	draw.Draw(dst, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), u, image.Point{}, draw.Over)
+}
+
+// opaque returns the colour with its alpha byte ignored.
+func opaque(c color.Color) color.RGBA {
+	if c == nil {
+		return color.RGBA{A: 0xff}
+	}
+
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(v, 1))
+}
@@ -0,0 +1,131 @@
+package resources
+
+import (
+	"sort"
+)
+
+// The library stores resources in maps, so anything that needs reproducible output walks them in ID order.
+
+func sortIDs(ids []IDType) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
+
+func sortedFletIDs(m map[FletID]*Flet) []FletID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]FletID, len(ids))
+	for i, id := range ids {
+		out[i] = FletID(id)
+	}
+
+	return out
+}
+
+func sortedPersIDs(m map[PersID]*Pers) []PersID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]PersID, len(ids))
+	for i, id := range ids {
+		out[i] = PersID(id)
+	}
+
+	return out
+}
+
+func sortedShipIDs(m map[ShipID]*Ship) []ShipID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]ShipID, len(ids))
+	for i, id := range ids {
+		out[i] = ShipID(id)
+	}
+
+	return out
+}
+
+func sortedSystIDs(m map[SystID]*Syst) []SystID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]SystID, len(ids))
+	for i, id := range ids {
+		out[i] = SystID(id)
+	}
+
+	return out
+}
+
+func sortedOutfIDs(m map[OutfID]*Outf) []OutfID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]OutfID, len(ids))
+	for i, id := range ids {
+		out[i] = OutfID(id)
+	}
+
+	return out
+}
+
+func sortedMisnIDs(m map[MisnID]*Misn) []MisnID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]MisnID, len(ids))
+	for i, id := range ids {
+		out[i] = MisnID(id)
+	}
+
+	return out
+}
+
+func sortedRankIDs(m map[RankID]*Rank) []RankID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]RankID, len(ids))
+	for i, id := range ids {
+		out[i] = RankID(id)
+	}
+
+	return out
+}
+
+func sortedSpobIDs(m map[SpobID]*Spob) []SpobID {
+	ids := make([]IDType, 0, len(m))
+	for id := range m {
+		ids = append(ids, IDType(id))
+	}
+	sortIDs(ids)
+
+	out := make([]SpobID, len(ids))
+	for i, id := range ids {
+		out[i] = SpobID(id)
+	}
+
+	return out
+}
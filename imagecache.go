@@ -0,0 +1,293 @@
+package resources
+
+import (
+	"container/list"
+	"errors"
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/imle/resourcefork"
+)
+
+// When a library is loaded lazily, PICT, rlëD, rlë8 and cicn resources keep their raw bytes and are only decoded when
+// asked for through the accessors below. Decoded images are kept in a cache bounded by the approximate size of
+// their pixel data, evicting the least recently used first.
+
+// DefaultImageCacheSize is the cache size used for lazily loaded libraries when LoadOptions doesn't set one.
+const DefaultImageCacheSize = 64 << 20
+
+var ErrResourceNotFound = errors.New("resource not found")
+
+type imageCacheKey struct {
+	typ   string
+	id    IDType
+	thumb int // Maximum thumbnail dimension, or 0 for the full image.
+}
+
+type imageCacheEntry struct {
+	key   imageCacheKey
+	value interface{}
+	size  int64
+}
+
+type imageCache struct {
+	lock    sync.Mutex
+	max     int64
+	size    int64
+	order   *list.List // Most recently used at the front.
+	entries map[imageCacheKey]*list.Element
+}
+
+func newImageCache(max int64) *imageCache {
+	return &imageCache{
+		max:     max,
+		order:   list.New(),
+		entries: map[imageCacheKey]*list.Element{},
+	}
+}
+
+// get returns the cached value for the key, or decodes, caches and returns it. Decoding happens outside the lock, so
+// two callers asking for the same image at once may both decode it.
+func (c *imageCache) get(key imageCacheKey, decode func() (interface{}, int64, error)) (interface{}, error) {
+	if c == nil {
+		v, _, err := decode()
+		return v, err
+	}
+
+	c.lock.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		c.lock.Unlock()
+		return e.Value.(*imageCacheEntry).value, nil
+	}
+	c.lock.Unlock()
+
+	v, size, err := decode()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*imageCacheEntry).value, nil
+	}
+
+	// Images larger than the whole cache are returned without being cached.
+	if size > c.max {
+		return v, nil
+	}
+
+	c.entries[key] = c.order.PushFront(&imageCacheEntry{key: key, value: v, size: size})
+	c.size += size
+
+	for c.size > c.max {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*imageCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+	}
+
+	return v, nil
+}
+
+func imageSize(img image.Image) int64 {
+	if img == nil {
+		return 0
+	}
+
+	return int64(img.Bounds().Dx()) * int64(img.Bounds().Dy()) * 4
+}
+
+// Lazy loaders store the raw resource instead of decoding it.
+var lazyResourceLoaders = map[string]resourceLoader{
+	"PICT": func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := &Pict{ID: PictID(res.ID), data: res.Data}
+		return func(rl *ResourceLibrary) { rl.Picts[v.ID] = v }, nil
+	},
+	"rlëD": func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := &RleD{ID: RleDID(res.ID), data: res.Data}
+		return func(rl *ResourceLibrary) { rl.RleDs[v.ID] = v }, nil
+	},
+	"rlë8": func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := &Rle8{ID: Rle8ID(res.ID), data: res.Data}
+		return func(rl *ResourceLibrary) { rl.Rle8s[v.ID] = v }, nil
+	},
+	"cicn": func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := &Cicn{ID: CicnID(res.ID), data: res.Data}
+		return func(rl *ResourceLibrary) { rl.Cicns[v.ID] = v }, nil
+	},
+}
+
+// Pict returns the decoded PICT resource, decoding it first if the library was loaded lazily.
+func (rl *ResourceLibrary) Pict(id PictID) (*Pict, error) {
+	p, ok := rl.Picts[id]
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+	if p.data == nil {
+		return p, nil
+	}
+
+	v, err := rl.images.get(imageCacheKey{typ: "PICT", id: IDType(id)}, func() (interface{}, int64, error) {
+		d, err := PictFromBytes(id, p.data)
+		if err != nil {
+			return nil, 0, err
+		}
+		return d, imageSize(d.Image), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*Pict), nil
+}
+
+// RleD returns the decoded rlëD resource, decoding it first if the library was loaded lazily.
+func (rl *ResourceLibrary) RleD(id RleDID) (*RleD, error) {
+	r, ok := rl.RleDs[id]
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+	if r.data == nil {
+		return r, nil
+	}
+
+	v, err := rl.images.get(imageCacheKey{typ: "rlëD", id: IDType(id)}, func() (interface{}, int64, error) {
+		d, err := RleDFromBytes(id, r.data)
+		if err != nil {
+			return nil, 0, err
+		}
+		return d, imageSize(d.Image), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*RleD), nil
+}
+
+// Rle8 returns the decoded rlë8 resource, decoding it first if the library was loaded lazily.
+func (rl *ResourceLibrary) Rle8(id Rle8ID) (*Rle8, error) {
+	r, ok := rl.Rle8s[id]
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+	if r.data == nil {
+		return r, nil
+	}
+
+	v, err := rl.images.get(imageCacheKey{typ: "rlë8", id: IDType(id)}, func() (interface{}, int64, error) {
+		d, err := Rle8FromBytes(id, r.data)
+		if err != nil {
+			return nil, 0, err
+		}
+		return d, imageSize(d.Image), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*Rle8), nil
+}
+
+// Cicn returns the decoded cicn resource, decoding it first if the library was loaded lazily.
+func (rl *ResourceLibrary) Cicn(id CicnID) (*Cicn, error) {
+	c, ok := rl.Cicns[id]
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+	if c.data == nil {
+		return c, nil
+	}
+
+	v, err := rl.images.get(imageCacheKey{typ: "cicn", id: IDType(id)}, func() (interface{}, int64, error) {
+		d, err := CicnFromBytes(id, c.data)
+		if err != nil {
+			return nil, 0, err
+		}
+		return d, imageSize(d.Image), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*Cicn), nil
+}
+
+// PictThumbnail returns the PICT scaled down to fit within max pixels in either direction, keeping its aspect ratio.
+// Pictures that already fit are returned at their full size. Thumbnails are cached like the images themselves.
+func (rl *ResourceLibrary) PictThumbnail(id PictID, max int) (image.Image, error) {
+	if max <= 0 {
+		return nil, errors.New("thumbnail size must be positive")
+	}
+
+	v, err := rl.images.get(imageCacheKey{typ: "PICT", id: IDType(id), thumb: max}, func() (interface{}, int64, error) {
+		p, err := rl.Pict(id)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		t := Thumbnail(p.Image, max)
+		return t, imageSize(t), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(image.Image), nil
+}
+
+// Thumbnail scales the image down to fit within max pixels in either direction, averaging the pixels each thumbnail
+// pixel covers. Images that already fit are returned unchanged.
+func Thumbnail(img image.Image, max int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= max && h <= max {
+		return img
+	}
+
+	tw, th := max, h*max/w
+	if h > w {
+		tw, th = w*max/h, max
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := b.Min.Y+ty*h/th, b.Min.Y+(ty+1)*h/th
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := b.Min.X+tx*w/tw, b.Min.X+(tx+1)*w/tw
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			out.Set(tx, ty, color.NRGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,225 @@
+package resources
+
+import (
+	"math"
+
+	"github.com/imle/resourcefork"
+)
+
+// The player's legal status is tracked per system rather than per government. When the player does something that
+// a government cares about, the change is applied to every system owned by that government or one of its allies,
+// and the opposite change is applied to every system owned by one of its enemies. Independent systems are never
+// affected.
+
+// LegalRecord holds the player's legal status for each of the 2048 possible systems, indexed by SystID - 128.
+type LegalRecord [2048]int16
+
+func (l *LegalRecord) Get(id SystID) int16 {
+	i := int(id) - resourcefork.ResourceForkIDOffset
+	if i < 0 || i >= len(l) {
+		return 0
+	}
+
+	return l[i]
+}
+
+func (l *LegalRecord) Set(id SystID, status int16) {
+	i := int(id) - resourcefork.ResourceForkIDOffset
+	if i < 0 || i >= len(l) {
+		return
+	}
+
+	l[i] = status
+}
+
+func (l *LegalRecord) Add(id SystID, delta int) {
+	l.Set(id, clampInt16(int(l.Get(id))+delta))
+}
+
+func clampInt16(v int) int16 {
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+
+	return int16(v)
+}
+
+type LegalAction int8
+
+const (
+	LegalActionKill    LegalAction = iota // Destroying a ship of the govt (KillPenalty).
+	LegalActionBoard                      // Boarding a ship of the govt (BoardPenalty).
+	LegalActionDisable                    // Disabling a ship of the govt (DisabPenalty).
+	LegalActionSmuggle                    // Being caught with illegal cargo by the govt (SmugPenalty).
+	LegalActionShoot                      // Firing on a ship of the govt (ShootPenalty, ignored by Nova).
+)
+
+// Penalty returns the legal status change the govt applies for the given action. Penalties are always returned as
+// a non-positive value, regardless of the sign used in the resource.
+func (g Govt) Penalty(action LegalAction) int16 {
+	var p int16
+	switch action {
+	case LegalActionKill:
+		p = g.KillPenalty
+	case LegalActionBoard:
+		p = g.BoardPenalty
+	case LegalActionDisable:
+		p = g.DisabPenalty
+	case LegalActionSmuggle:
+		p = g.SmugPenalty
+	case LegalActionShoot:
+		return 0
+	}
+
+	if p > 0 {
+		return -p
+	}
+
+	return p
+}
+
+type LegalLabel struct {
+	Min   int16 // The lowest legal status this label applies to.
+	Label string
+}
+
+// DefaultLegalLabels is the ladder of standing names used when reporting legal status, in ascending order of Min.
+var DefaultLegalLabels = []LegalLabel{
+	{Min: math.MinInt16, Label: "Public Enemy"},
+	{Min: -1999, Label: "Dangerous Criminal"},
+	{Min: -999, Label: "Wanted"},
+	{Min: -299, Label: "Fugitive"},
+	{Min: -99, Label: "Criminal"},
+	{Min: -29, Label: "Offender"},
+	{Min: 0, Label: "Clean"},
+	{Min: 30, Label: "Good"},
+	{Min: 100, Label: "Decent"},
+	{Min: 300, Label: "Upstanding"},
+	{Min: 1000, Label: "Honorable"},
+	{Min: 2000, Label: "Respected"},
+	{Min: 4000, Label: "Admired"},
+	{Min: 8000, Label: "Heroic"},
+}
+
+type LegalStanding struct {
+	Syst    SystID
+	Govt    GovtID
+	Status  int16
+	Label   string
+	Hostile bool // The owning govt's tolerance for crime has been exceeded.
+}
+
+type LegalEngine struct {
+	Govts     map[GovtID]*Govt
+	Systs     map[SystID]*Syst
+	Relations *GovtRelations
+	Labels    []LegalLabel
+}
+
+func NewLegalEngine(lib *ResourceLibrary) *LegalEngine {
+	return &LegalEngine{
+		Govts:     lib.Govts,
+		Systs:     lib.Systs,
+		Relations: NewGovtRelations(lib.Govts),
+		Labels:    DefaultLegalLabels,
+	}
+}
+
+// Adjust applies delta to every system owned by govt or its allies, and -delta to every system owned by its enemies.
+func (e *LegalEngine) Adjust(record *LegalRecord, govt GovtID, delta int16) {
+	if govt == GovtIDIndependent || delta == 0 {
+		return
+	}
+
+	for id, syst := range e.Systs {
+		if syst.Govt == GovtIDIndependent {
+			continue
+		}
+
+		switch e.Relations.Relation(govt, syst.Govt) {
+		case GovtRelationAlly:
+			record.Add(id, int(delta))
+		case GovtRelationEnemy:
+			record.Add(id, -int(delta))
+		}
+	}
+}
+
+// Commit records the player committing the given action against a ship of govt.
+func (e *LegalEngine) Commit(record *LegalRecord, govt GovtID, action LegalAction) {
+	g, ok := e.Govts[govt]
+	if !ok {
+		return
+	}
+
+	e.Adjust(record, govt, g.Penalty(action))
+}
+
+// InitFromChar sets up the starting legal status described by a chär resource. Systems owned by each listed govt or
+// one of its allies are set to the corresponding Status, and systems owned by its enemies to the negated Status.
+func (e *LegalEngine) InitFromChar(record *LegalRecord, c *Char) {
+	for i, govt := range c.Govt {
+		if govt == GovtIDIndependent || govt == 0 {
+			continue
+		}
+
+		for id, syst := range e.Systs {
+			if syst.Govt == GovtIDIndependent {
+				continue
+			}
+
+			switch e.Relations.Relation(govt, syst.Govt) {
+			case GovtRelationAlly:
+				record.Set(id, int16(c.Status[i]))
+			case GovtRelationEnemy:
+				record.Set(id, -int16(c.Status[i]))
+			}
+		}
+	}
+}
+
+func (e *LegalEngine) Label(status int16) string {
+	label := ""
+	for _, l := range e.Labels {
+		if status < l.Min {
+			break
+		}
+		label = l.Label
+	}
+
+	return label
+}
+
+func (e *LegalEngine) Standing(record *LegalRecord, id SystID) LegalStanding {
+	status := record.Get(id)
+
+	s := LegalStanding{
+		Syst:   id,
+		Govt:   GovtIDIndependent,
+		Status: status,
+		Label:  e.Label(status),
+	}
+
+	if syst, ok := e.Systs[id]; ok {
+		s.Govt = syst.Govt
+		if g, ok := e.Govts[syst.Govt]; ok {
+			s.Hostile = g.Flags.AlwaysAttack || int(status) < -int(g.CrimeTol)
+		}
+	}
+
+	return s
+}
+
+// Standings returns the standing in every known system, ordered by system ID.
+func (e *LegalEngine) Standings(record *LegalRecord) []LegalStanding {
+	ids := sortedSystIDs(e.Systs)
+	out := make([]LegalStanding, len(ids))
+	for i, id := range ids {
+		out[i] = e.Standing(record, id)
+	}
+
+	return out
+}
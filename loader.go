@@ -0,0 +1,304 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/imle/resourcefork"
+)
+
+// LoadProgress is called each time a resource has been decoded, with the number of resources of that type decoded
+// so far and the total. Calls are serialised, but come from the loader's worker goroutines.
+type LoadProgress func(typ string, done int, total int)
+
+type LoadOptions struct {
+	Workers  int // Number of resources decoded at once. Defaults to runtime.NumCPU().
+	Progress LoadProgress
+
+	// Lazy leaves PICT, rlëD, rlë8 and cicn resources undecoded until they're fetched with the library's Pict, RleD,
+	// Rle8 and Cicn methods. Decoded images are cached up to ImageCacheSize bytes, DefaultImageCacheSize if unset.
+	Lazy           bool
+	ImageCacheSize int64
+}
+
+// resourceLoader decodes one resource and returns a function that stores the result in the library. The store
+// functions are run one at a time, so they can write to the library's maps directly.
+type resourceLoader func(res resourcefork.Resource) (store func(rl *ResourceLibrary), err error)
+
+// resourceLoaders lists every resource type the library understands, in the order they're loaded.
+var resourceLoaders = []struct {
+	typ  string
+	load resourceLoader
+}{
+	{"cölr", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		// Only the first cölr resource is used.
+		if res.ID != 128 {
+			return func(*ResourceLibrary) {}, nil
+		}
+		v := ColrFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Colr = v }, nil
+	}},
+	{"bööm", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := BoomFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Booms[BoomID(res.ID)] = v }, nil
+	}},
+	{"chär", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := CharFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Chars[CharID(res.ID)] = v }, nil
+	}},
+	{"cicn", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v, err := CicnFromBytes(CicnID(res.ID), res.Data)
+		if err != nil {
+			return nil, err
+		}
+		return func(rl *ResourceLibrary) { rl.Cicns[CicnID(res.ID)] = v }, nil
+	}},
+	{"crön", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := CronFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Crons[CronID(res.ID)] = v }, nil
+	}},
+	{"dësc", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := DescFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Descs[DescID(res.ID)] = v }, nil
+	}},
+	{"düde", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := DudeFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Dudes[DudeID(res.ID)] = v }, nil
+	}},
+	{"flët", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := FletFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Flets[FletID(res.ID)] = v }, nil
+	}},
+	{"gövt", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := GovtFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Govts[GovtID(res.ID)] = v }, nil
+	}},
+	{"ïntf", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := IntfFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Intfs[IntfID(res.ID)] = v }, nil
+	}},
+	{"jünk", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := JunkFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Junks[JunkID(res.ID)] = v }, nil
+	}},
+	{"mïsn", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := MisnFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Misns[MisnID(res.ID)] = v }, nil
+	}},
+	{"nëbu", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := NebuFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Nebus[NebuID(res.ID)] = v }, nil
+	}},
+	{"öops", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := OopsFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Oopss[OopsID(res.ID)] = v }, nil
+	}},
+	{"oütf", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := OutfFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Outfs[OutfID(res.ID)] = v }, nil
+	}},
+	{"përs", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := PersFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Perss[PersID(res.ID)] = v }, nil
+	}},
+	{"PICT", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v, err := PictFromBytes(PictID(res.ID), res.Data)
+		if err != nil {
+			return nil, err
+		}
+		return func(rl *ResourceLibrary) { rl.Picts[PictID(res.ID)] = v }, nil
+	}},
+	{"ränk", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := RankFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Ranks[RankID(res.ID)] = v }, nil
+	}},
+	{"rlëD", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v, err := RleDFromBytes(RleDID(res.ID), res.Data)
+		if err != nil {
+			return nil, err
+		}
+		return func(rl *ResourceLibrary) { rl.RleDs[RleDID(res.ID)] = v }, nil
+	}},
+	{"rlë8", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v, err := Rle8FromBytes(Rle8ID(res.ID), res.Data)
+		if err != nil {
+			return nil, err
+		}
+		return func(rl *ResourceLibrary) { rl.Rle8s[Rle8ID(res.ID)] = v }, nil
+	}},
+	{"röid", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := RoidFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Roids[RoidID(res.ID)] = v }, nil
+	}},
+	{"shän", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := ShanFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Shans[ShanID(res.ID)] = v }, nil
+	}},
+	{"shïp", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := ShipFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Ships[ShipID(res.ID)] = v }, nil
+	}},
+	{"snd", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := SndFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Snds[SndID(res.ID)] = v }, nil
+	}},
+	{"spïn", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := SpinFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Spins[SpinID(res.ID)] = v }, nil
+	}},
+	{"spöb", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := SpobFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Spobs[SpobID(res.ID)] = v }, nil
+	}},
+	{"STR#", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := StrAFromResource(res)
+		return func(rl *ResourceLibrary) { rl.StrAs[StrAID(res.ID)] = v }, nil
+	}},
+	{"sÿst", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := SystFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Systs[SystID(res.ID)] = v }, nil
+	}},
+	{"wëap", func(res resourcefork.Resource) (func(*ResourceLibrary), error) {
+		v := WeapFromResource(res)
+		return func(rl *ResourceLibrary) { rl.Weaps[WeapID(res.ID)] = v }, nil
+	}},
+}
+
+type loadJob struct {
+	load resourceLoader
+	res  resourcefork.Resource
+}
+
+// LoadResourceLibrary decodes every resource in the fork using a bounded pool of workers. Loading stops at the first
+// resource that fails to decode, or when the context is cancelled, and returns that error.
+func LoadResourceLibrary(ctx context.Context, rf *resourcefork.ResourceFork, opts *LoadOptions) (*ResourceLibrary, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rl := newResourceLibrary()
+	rl.source = rf
+	if opts.Lazy {
+		size := opts.ImageCacheSize
+		if size <= 0 {
+			size = DefaultImageCacheSize
+		}
+		rl.images = newImageCache(size)
+	}
+
+	var (
+		lock     sync.Mutex
+		firstErr error
+		done     = map[string]int{}
+		total    = map[string]int{}
+	)
+
+	fail := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	jobs := make(chan loadJob)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				store, err := decodeResource(job.load, job.res)
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				lock.Lock()
+				store(rl)
+				done[job.res.Type]++
+				if opts.Progress != nil {
+					opts.Progress(job.res.Type, done[job.res.Type], total[job.res.Type])
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, l := range resourceLoaders {
+		total[l.typ] = len(rf.Resources[l.typ])
+	}
+
+feed:
+	for _, l := range resourceLoaders {
+		resources := rf.Resources[l.typ]
+
+		load := l.load
+		if lazy, ok := lazyResourceLoaders[l.typ]; ok && opts.Lazy {
+			load = lazy
+		}
+
+		ids := make([]int, 0, len(resources))
+		for id := range resources {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			res := resources[uint16(id)]
+			res.Type, res.ID = l.typ, uint16(id)
+
+			select {
+			case jobs <- loadJob{load: load, res: res}:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// decodeResource runs a loader, turning the panics the decoders raise on malformed data into errors.
+func decodeResource(load resourceLoader, res resourcefork.Resource) (store func(*ResourceLibrary), err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s %d: %v", res.Type, res.ID, r)
+		}
+	}()
+
+	store, err = load(res)
+	if err != nil {
+		err = fmt.Errorf("%s %d: %w", res.Type, res.ID, err)
+	}
+
+	return store, err
+}
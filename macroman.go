@@ -0,0 +1,56 @@
+package resources
+
+import "fmt"
+
+// Resource type codes, names and strings are stored as Mac OS Roman text. 0xCA is a no-break space and 0xF0 the Apple
+// logo, which Unicode only has in the private use area.
+
+const macRomanHigh = "ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+	"¿¡¬√ƒ≈∆«»…\u00a0ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔ\uf8ffÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ"
+
+var (
+	macRomanRunes   = []rune(macRomanHigh)
+	macRomanReverse = func() map[rune]byte {
+		m := make(map[rune]byte, len(macRomanRunes))
+		for i, r := range macRomanRunes {
+			m[r] = byte(0x80 + i)
+		}
+		return m
+	}()
+)
+
+func init() {
+	if len(macRomanRunes) != 128 {
+		panic(fmt.Sprintf("macRomanHigh has %d runes, want 128", len(macRomanRunes)))
+	}
+}
+
+func decodeMacRoman(b []byte) string {
+	out := make([]rune, len(b))
+	for i, c := range b {
+		if c < 0x80 {
+			out[i] = rune(c)
+		} else {
+			out[i] = macRomanRunes[c-0x80]
+		}
+	}
+
+	return string(out)
+}
+
+// encodeMacRoman converts a string to Mac OS Roman, replacing characters that can't be represented with '?'.
+func encodeMacRoman(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch b, ok := macRomanReverse[r]; {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case ok:
+			out = append(out, b)
+		default:
+			out = append(out, '?')
+		}
+	}
+
+	return out
+}
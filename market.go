@@ -0,0 +1,305 @@
+package resources
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// When the player visits a shipyard or outfitter, Nova lists every shïp or oütf that passes its checks, in order of
+// descending DispWeight. An item is offered if:
+//
+//  - its TechLevel is no higher than the spöb's, or matches one of its SpecialTech levels,
+//  - (outfits) its RequireGovt allows the spöb's govt,
+//  - (ships) its AppearOn test passes,
+//  - its Availability test passes, and
+//  - its BuyRandom roll succeeds.
+//
+// Items that fail the Require bits are still listed but can't be bought. Flags on either resource hide items that
+// fail Availability or Require unless the player already has one, and an available item with HideHigherDispWeight
+// (outfits) or HideShipTypesOfEqualDisplayWeight (ships) hides every higher numbered item of the same DispWeight.
+
+type MarketCriterion int8
+
+const (
+	MarketCriterionNone         MarketCriterion = iota // The item is offered.
+	MarketCriterionFacility                            // The spöb has no shipyard or outfitter.
+	MarketCriterionTechLevel                           // The item's tech level is too high.
+	MarketCriterionRequireGovt                         // The item isn't sold by the spöb's govt.
+	MarketCriterionAppearOn                            // The ship's AppearOn test is false.
+	MarketCriterionAvailability                        // The item's Availability test is false.
+	MarketCriterionRequire                             // The player's contribute bits don't satisfy Require.
+	MarketCriterionBuyRandom                           // The BuyRandom roll failed.
+	MarketCriterionDispWeight                          // A lower numbered item of the same DispWeight hides it.
+)
+
+func (c MarketCriterion) String() string {
+	switch c {
+	case MarketCriterionFacility:
+		return "Facility"
+	case MarketCriterionTechLevel:
+		return "TechLevel"
+	case MarketCriterionRequireGovt:
+		return "RequireGovt"
+	case MarketCriterionAppearOn:
+		return "AppearOn"
+	case MarketCriterionAvailability:
+		return "Availability"
+	case MarketCriterionRequire:
+		return "Require"
+	case MarketCriterionBuyRandom:
+		return "BuyRandom"
+	case MarketCriterionDispWeight:
+		return "DispWeight"
+	default:
+		return "None"
+	}
+}
+
+// MarketVerdict describes one shïp or oütf. Only one of Ship and Outf is set.
+type MarketVerdict struct {
+	Ship ShipID
+	Outf OutfID
+
+	Listed  bool            // The item appears in the list.
+	Buyable bool            // The item appears and can be bought.
+	Failed  MarketCriterion // Why the item is hidden, or why it can't be bought.
+	Reason  string
+	Chance  float64 // Probability of the item being offered once every deterministic criterion has passed.
+
+	dispWeight int16
+	hideHigher bool
+}
+
+// MarketInventory is what a shipyard or outfitter shows, in display order, along with every item it doesn't show,
+// in ID order.
+type MarketInventory struct {
+	Spob   SpobID
+	Listed []MarketVerdict
+	Hidden []MarketVerdict
+}
+
+type MarketOracle struct {
+	Lib        *ResourceLibrary
+	Relations  *GovtRelations
+	Contribute FlagMask64 // Extra contribute bits, e.g. from running crons.
+
+	// Rand is used for the BuyRandom rolls. If nil, the rolls are skipped and only reported through Chance.
+	Rand *rand.Rand
+}
+
+func NewMarketOracle(lib *ResourceLibrary) *MarketOracle {
+	return &MarketOracle{
+		Lib:       lib,
+		Relations: NewGovtRelations(lib.Govts),
+	}
+}
+
+// ShipyardAt returns the ships for sale at the spöb to the pilot.
+func (o *MarketOracle) ShipyardAt(spob *Spob, p *NpiL) *MarketInventory {
+	ids := sortedShipIDs(o.Lib.Ships)
+	verdicts := make([]MarketVerdict, len(ids))
+	for i, id := range ids {
+		verdicts[i] = o.checkShip(spob, p, o.Lib.Ships[id])
+	}
+
+	return o.inventory(spob, verdicts)
+}
+
+// OutfitterAt returns the outfits for sale at the spöb to the pilot.
+func (o *MarketOracle) OutfitterAt(spob *Spob, p *NpiL) *MarketInventory {
+	ids := sortedOutfIDs(o.Lib.Outfs)
+	verdicts := make([]MarketVerdict, len(ids))
+	for i, id := range ids {
+		verdicts[i] = o.checkOutf(spob, p, o.Lib.Outfs[id])
+	}
+
+	return o.inventory(spob, verdicts)
+}
+
+// inventory applies the DispWeight hiding to verdicts in ID order and sorts the result.
+func (o *MarketOracle) inventory(spob *Spob, verdicts []MarketVerdict) *MarketInventory {
+	hiddenBy := map[int16]MarketVerdict{}
+	for i, v := range verdicts {
+		if !v.Listed {
+			continue
+		}
+
+		if by, ok := hiddenBy[v.dispWeight]; ok {
+			verdicts[i] = MarketVerdict{
+				Ship:   v.Ship,
+				Outf:   v.Outf,
+				Failed: MarketCriterionDispWeight,
+				Reason: fmt.Sprintf("hidden by %s", by.name()),
+			}
+			continue
+		}
+		if v.hideHigher && v.Buyable {
+			hiddenBy[v.dispWeight] = v
+		}
+	}
+
+	inv := &MarketInventory{Spob: spob.ID}
+	for _, v := range verdicts {
+		if v.Listed {
+			inv.Listed = append(inv.Listed, v)
+		} else {
+			inv.Hidden = append(inv.Hidden, v)
+		}
+	}
+	sort.SliceStable(inv.Listed, func(i, j int) bool { return inv.Listed[i].dispWeight > inv.Listed[j].dispWeight })
+
+	return inv
+}
+
+func (v MarketVerdict) name() string {
+	if v.Ship != 0 {
+		return fmt.Sprintf("shïp %d", v.Ship)
+	}
+
+	return fmt.Sprintf("oütf %d", v.Outf)
+}
+
+// techAllowed reports whether the spöb sells items of the tech level.
+func techAllowed(spob *Spob, level TechLevel) bool {
+	if level <= spob.TechLevel {
+		return true
+	}
+
+	for _, t := range spob.SpecialTech {
+		if t > 0 && t == level {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *MarketOracle) roll(v MarketVerdict, buyRandom int16) MarketVerdict {
+	v.Chance = 1
+	if buyRandom >= 100 || buyRandom <= 0 {
+		return v
+	}
+
+	v.Chance = float64(buyRandom) / 100
+	if o.Rand != nil && o.Rand.Intn(100) >= int(buyRandom) {
+		v.Listed, v.Buyable = false, false
+		v.Failed = MarketCriterionBuyRandom
+		v.Reason = fmt.Sprintf("BuyRandom roll failed (%d%%)", buyRandom)
+	}
+
+	return v
+}
+
+func (o *MarketOracle) checkShip(spob *Spob, p *NpiL, s *Ship) MarketVerdict {
+	v := MarketVerdict{Ship: s.ID, dispWeight: s.DispWeight, hideHigher: s.Flags.HideShipTypesOfEqualDisplayWeight}
+	fail := func(c MarketCriterion, format string, args ...interface{}) MarketVerdict {
+		v.Listed, v.Buyable, v.Failed, v.Reason = false, false, c, fmt.Sprintf(format, args...)
+		return v
+	}
+
+	if !spob.Flags.HasShipyard {
+		return fail(MarketCriterionFacility, "spöb %d has no shipyard", spob.ID)
+	}
+	if !techAllowed(spob, s.TechLevel) {
+		return fail(MarketCriterionTechLevel, "tech level %d is above %d", s.TechLevel, spob.TechLevel)
+	}
+	if ok, err := s.AppearOn.Eval(p); err != nil {
+		return fail(MarketCriterionAppearOn, "AppearOn %q: %v", s.AppearOn, err)
+	} else if !ok {
+		return fail(MarketCriterionAppearOn, "AppearOn %q is false", s.AppearOn)
+	}
+
+	v.Listed, v.Buyable = true, true
+	owned := p.ShipClass == s.ID
+
+	if ok, err := s.Availability.Eval(p); err != nil || !ok {
+		if s.Flags.ShowOnlyIfAvailability && !owned {
+			return fail(MarketCriterionAvailability, "Availability %q is false", s.Availability)
+		}
+		v.Buyable, v.Failed, v.Reason = false, MarketCriterionAvailability, fmt.Sprintf("Availability %q is false", s.Availability)
+	}
+
+	if c := p.Contribute(o.Lib) | o.Contribute; s.Require&c != s.Require {
+		reason := fmt.Sprintf("missing contribute bits %#016x", uint64(s.Require&^c))
+		if s.Flags.ShowOnlyIfRequire && !owned {
+			return fail(MarketCriterionRequire, "%s", reason)
+		}
+		if v.Buyable {
+			v.Buyable, v.Failed, v.Reason = false, MarketCriterionRequire, reason
+		}
+	}
+
+	return o.roll(v, s.BuyRandom)
+}
+
+func (o *MarketOracle) checkOutf(spob *Spob, p *NpiL, out *Outf) MarketVerdict {
+	v := MarketVerdict{Outf: out.ID, dispWeight: out.DispWeight, hideHigher: out.Flags.HideHigherDispWeight}
+	fail := func(c MarketCriterion, format string, args ...interface{}) MarketVerdict {
+		v.Listed, v.Buyable, v.Failed, v.Reason = false, false, c, fmt.Sprintf(format, args...)
+		return v
+	}
+
+	if !spob.Flags.HasOutfitter {
+		return fail(MarketCriterionFacility, "spöb %d has no outfitter", spob.ID)
+	}
+	if !techAllowed(spob, out.TechLevel) {
+		return fail(MarketCriterionTechLevel, "tech level %d is above %d", out.TechLevel, spob.TechLevel)
+	}
+	if ok, why := o.requireGovtAllows(out.RequireGovt, spob.Govt); !ok {
+		return fail(MarketCriterionRequireGovt, "%s", why)
+	}
+
+	v.Listed, v.Buyable = true, true
+	owned := p.HasOutfit(out.ID)
+
+	if ok, err := out.Availability.Eval(p); err != nil || !ok {
+		if out.Flags.AvailableBitsOrHasOne && !owned {
+			return fail(MarketCriterionAvailability, "Availability %q is false", out.Availability)
+		}
+		v.Buyable, v.Failed, v.Reason = false, MarketCriterionAvailability, fmt.Sprintf("Availability %q is false", out.Availability)
+	}
+
+	if c := p.Contribute(o.Lib) | o.Contribute; out.Require&c != out.Require {
+		reason := fmt.Sprintf("missing contribute bits %#016x", uint64(out.Require&^c))
+		if out.Flags.RequireBitsOrHasOne && !owned {
+			return fail(MarketCriterionRequire, "%s", reason)
+		}
+		if !owned && v.Buyable {
+			v.Buyable, v.Failed, v.Reason = false, MarketCriterionRequire, reason
+		}
+	}
+
+	return o.roll(v, out.BuyRandom)
+}
+
+func (o *MarketOracle) requireGovtAllows(r RequireGovtID, owner GovtID) (bool, string) {
+	if r.All() || r < 128 {
+		return true, ""
+	}
+
+	govt := r.Govt()
+	allied := owner != GovtIDIndependent && o.Relations.IsAlly(govt, owner)
+	independent := owner == GovtIDIndependent
+
+	switch {
+	case r.GovtAndAllies():
+		if !allied {
+			return false, fmt.Sprintf("only sold by gövt %d and its allies", govt)
+		}
+	case r.GovtAndAlliesAndIndependent():
+		if !allied && !independent {
+			return false, fmt.Sprintf("only sold by gövt %d, its allies and independents", govt)
+		}
+	case r.NotGovtAndAllies():
+		if allied {
+			return false, fmt.Sprintf("not sold by gövt %d or its allies", govt)
+		}
+	case r.NotGovtAndAlliesAndIndependent():
+		if allied || independent {
+			return false, fmt.Sprintf("not sold by gövt %d, its allies or independents", govt)
+		}
+	}
+
+	return true, ""
+}
@@ -0,0 +1,113 @@
+package resources
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// A main menu mockup composes the 1024x768 main screen from the cölr resource: the background PICT, the six buttons
+// from spïns 600-605, the logo from spïn 606, the rollover from spïn 607 and the sliding buttons from spïns 608-610,
+// each drawn from its first frame at the cölr position, and the loading progress bar on top.
+
+const (
+	MainMenuWidth  = 1024
+	MainMenuHeight = 768
+
+	PictIDMainMenuBackground PictID = 8000
+
+	SpinIDMainMenuButton1 SpinID = 600
+	SpinIDMainMenuSlide1  SpinID = 608
+)
+
+var ErrNoColr = errors.New("no cölr resource loaded")
+
+type MainMenuMockup struct {
+	Lib *ResourceLibrary
+
+	Background PictID  // Drawn at the top left corner, if present.
+	Progress   float64 // Fraction of the progress bar that's filled, from 0 to 1. Negative hides the bar.
+}
+
+func NewMainMenuMockup(lib *ResourceLibrary) *MainMenuMockup {
+	return &MainMenuMockup{
+		Lib:        lib,
+		Background: PictIDMainMenuBackground,
+		Progress:   0.4,
+	}
+}
+
+// Render returns the mockup. Sprites whose spïn isn't in the library are left out.
+func (m *MainMenuMockup) Render() (*image.NRGBA, error) {
+	colr := m.Lib.Colr
+	if colr == nil {
+		return nil, ErrNoColr
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, MainMenuWidth, MainMenuHeight))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	if _, ok := m.Lib.Picts[m.Background]; ok {
+		pict, err := m.Lib.Pict(m.Background)
+		if err != nil {
+			return nil, err
+		}
+		draw.Draw(dst, dst.Bounds(), pict.Image, pict.Image.Bounds().Min, draw.Src)
+	}
+
+	placements := []struct {
+		spin SpinID
+		at   image.Point
+	}{
+		{SpinIDMainMenuButton1, colr.Button1},
+		{SpinIDMainMenuButton1 + 1, colr.Button2},
+		{SpinIDMainMenuButton1 + 2, colr.Button3},
+		{SpinIDMainMenuButton1 + 3, colr.Button4},
+		{SpinIDMainMenuButton1 + 4, colr.Button5},
+		{SpinIDMainMenuButton1 + 5, colr.Button6},
+		{SpinIDMainScreenLogo, colr.Logo},
+		{SpinIDMainScreenRolloverImages, colr.Rollover},
+		{SpinIDMainMenuSlide1, colr.Slide1},
+		{SpinIDMainMenuSlide1 + 1, colr.Slide2},
+		{SpinIDMainMenuSlide1 + 2, colr.Slide3},
+	}
+
+	for _, p := range placements {
+		spin, ok := m.Lib.Spins[p.spin]
+		if !ok {
+			continue
+		}
+
+		frames, err := spin.Frames(m.Lib)
+		if err != nil {
+			return nil, err
+		}
+		if len(frames) == 0 {
+			continue
+		}
+
+		f := frames[0]
+		r := f.Bounds().Sub(f.Bounds().Min).Add(p.at)
+		draw.Draw(dst, r, f, f.Bounds().Min, draw.Over)
+	}
+
+	if m.Progress >= 0 {
+		m.progressBar(dst, colr)
+	}
+
+	return dst, nil
+}
+
+// progressBar draws the bar, whose rectangle is relative to the centre of the screen.
+func (m *MainMenuMockup) progressBar(dst draw.Image, colr *Colr) {
+	r := colr.ProgressBar.Canon().Add(image.Pt(MainMenuWidth/2, MainMenuHeight/2))
+	if r.Empty() {
+		return
+	}
+
+	inner := r.Inset(1)
+	hudBar(dst, inner, 0, 1, colr.ProgDim)
+	hudBar(dst, inner, 0, m.Progress, colr.ProgBright)
+	hudOutline(dst, r, opaque(colr.ProgOutline))
+}
@@ -2,6 +2,8 @@ package resources
 
 import (
 	"bytes"
+	"encoding/binary"
+	"image"
 )
 
 type IDType int16
@@ -53,3 +55,13 @@ type Resource interface {
 func byteString(b []byte, length int) string {
 	return string(bytes.Runes(b[:bytes.IndexByte(b[:length], 0)]))
 }
+
+// quickDrawRect decodes a QuickDraw Rect, which is stored as top, left, bottom, right.
+func quickDrawRect(b []byte) image.Rectangle {
+	return image.Rect(
+		int(int16(binary.BigEndian.Uint16(b[2:]))),
+		int(int16(binary.BigEndian.Uint16(b[0:]))),
+		int(int16(binary.BigEndian.Uint16(b[6:]))),
+		int(int16(binary.BigEndian.Uint16(b[4:]))),
+	)
+}
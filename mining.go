@@ -0,0 +1,266 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// A system's asteroid field holds Syst.Asteroids asteroids, each of a type picked evenly from Syst.AstTypes. When an
+// asteroid is destroyed it releases its yield and breaks into FragCount fragments, each of either fragment type, which
+// can be destroyed in turn. The mining model expands these trees into expected values:
+//
+//  Yield      YieldFraction of YieldQty per destroyed asteroid, 0.5 for an even roll between nothing and YieldQty.
+//  Collected  Everything with a mining scoop, or WithoutScoop of it otherwise.
+//  Damage     Mass damage plus half the energy damage per shot, ten times the mass damage for weapons with
+//             TenfoldDamageToAsteroids, at each weapon's reload rate.
+//
+// Trees are cut off after MaxDepth generations of fragments, so types that fragment into themselves stay finite.
+
+type MiningModel struct {
+	Lib *ResourceLibrary
+
+	YieldFraction float64
+	WithoutScoop  float64
+	MaxDepth      int
+}
+
+func NewMiningModel(lib *ResourceLibrary) *MiningModel {
+	return &MiningModel{
+		Lib:           lib,
+		YieldFraction: 0.5,
+		WithoutScoop:  0,
+		MaxDepth:      8,
+	}
+}
+
+// RoidYield is what destroying an asteroid and every fragment it breaks into is expected to release.
+type RoidYield struct {
+	Roid RoidID // Zero when averaged over several types.
+
+	Destroyed   float64                   // Asteroids destroyed, counting the first.
+	Strength    float64                   // Total damage needed to destroy them.
+	Commodities map[CommodityType]float64 // Expected tons of each commodity.
+	Junks       map[JunkID]float64        // Expected tons of each jünk.
+}
+
+// Tons returns the expected tons of every commodity and jünk together.
+func (y *RoidYield) Tons() float64 {
+	t := 0.0
+	for _, v := range y.Commodities {
+		t += v
+	}
+	for _, v := range y.Junks {
+		t += v
+	}
+
+	return t
+}
+
+func newRoidYield(id RoidID) *RoidYield {
+	return &RoidYield{Roid: id, Commodities: map[CommodityType]float64{}, Junks: map[JunkID]float64{}}
+}
+
+// add adds w times o to the yield.
+func (y *RoidYield) add(o *RoidYield, w float64) {
+	y.Destroyed += o.Destroyed * w
+	y.Strength += o.Strength * w
+	for t, v := range o.Commodities {
+		y.Commodities[t] += v * w
+	}
+	for id, v := range o.Junks {
+		y.Junks[id] += v * w
+	}
+}
+
+// Roid returns the expected yield of destroying an asteroid of the type and all its fragments.
+func (m *MiningModel) Roid(id RoidID) (*RoidYield, error) {
+	return m.expand(id, 0, map[[2]int]*RoidYield{})
+}
+
+func (m *MiningModel) expand(id RoidID, depth int, memo map[[2]int]*RoidYield) (*RoidYield, error) {
+	key := [2]int{int(id), depth}
+	if y, ok := memo[key]; ok {
+		return y, nil
+	}
+
+	r, ok := m.Lib.Roids[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown röid %d", id)
+	}
+
+	y := newRoidYield(id)
+	y.Destroyed = 1
+	y.Strength = float64(r.Strength)
+
+	qty := float64(r.YieldQty) * m.YieldFraction
+	if t, ok := r.Commodity(); ok && qty > 0 {
+		y.Commodities[t] += qty
+	}
+	if j, ok := r.Junk(); ok && qty > 0 {
+		y.Junks[j] += qty
+	}
+
+	if frags := r.Fragments(); len(frags) > 0 && r.FragCount > 0 && depth < m.MaxDepth {
+		w := float64(r.FragCount) / float64(len(frags))
+		for _, f := range frags {
+			fy, err := m.expand(f, depth+1, memo)
+			if err != nil {
+				return nil, err
+			}
+			y.add(fy, w)
+		}
+	}
+
+	memo[key] = y
+	return y, nil
+}
+
+// SystYield is the expected haul from mining out a system's asteroid field.
+type SystYield struct {
+	Syst      SystID
+	Asteroids int
+	Roids     []RoidID
+
+	PerAsteroid *RoidYield // Collected yield of one asteroid and its fragments, averaged over the types.
+	Field       *RoidYield // Collected yield of the whole field.
+
+	Collected     float64       // Fraction of the yield the loadout collects.
+	DPS           float64       // The loadout's damage per second against asteroids.
+	ClearTime     time.Duration // Time to destroy the whole field, or zero if the loadout can't damage asteroids.
+	TonsPerMinute float64
+}
+
+// Syst returns the expected yield of the system's asteroid field for the loadout. With a nil loadout everything is
+// collected and no times are worked out.
+func (m *MiningModel) Syst(id SystID, l *Loadout) (*SystYield, error) {
+	s, ok := m.Lib.Systs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown sÿst %d", id)
+	}
+
+	out := &SystYield{
+		Syst:        id,
+		Asteroids:   int(s.Asteroids),
+		PerAsteroid: newRoidYield(0),
+		Field:       newRoidYield(0),
+		Collected:   1,
+	}
+
+	for _, r := range s.AstTypes.RoidIDs() {
+		if _, ok := m.Lib.Roids[r]; ok {
+			out.Roids = append(out.Roids, r)
+		}
+	}
+	if out.Asteroids <= 0 || len(out.Roids) == 0 {
+		out.Asteroids = 0
+		return out, nil
+	}
+
+	if l != nil {
+		var err error
+		if out.Collected, err = m.collected(l); err != nil {
+			return nil, err
+		}
+		if out.DPS, err = m.DPS(l); err != nil {
+			return nil, err
+		}
+	}
+
+	memo := map[[2]int]*RoidYield{}
+	for _, r := range out.Roids {
+		y, err := m.expand(r, 0, memo)
+		if err != nil {
+			return nil, err
+		}
+		out.PerAsteroid.add(y, 1/float64(len(out.Roids)))
+	}
+
+	// Only the yield depends on what's collected, not the destroying.
+	for t, v := range out.PerAsteroid.Commodities {
+		out.PerAsteroid.Commodities[t] = v * out.Collected
+	}
+	for j, v := range out.PerAsteroid.Junks {
+		out.PerAsteroid.Junks[j] = v * out.Collected
+	}
+	out.Field.add(out.PerAsteroid, float64(out.Asteroids))
+
+	if out.DPS > 0 {
+		secs := out.Field.Strength / out.DPS
+		out.ClearTime = time.Duration(secs * float64(time.Second))
+		if secs > 0 {
+			out.TonsPerMinute = out.Field.Tons() / secs * 60
+		}
+	}
+
+	return out, nil
+}
+
+// Rank returns the yield of every system with asteroids, best first: by tons per minute when the loadout can damage
+// asteroids, otherwise by the field's tons.
+func (m *MiningModel) Rank(l *Loadout) ([]*SystYield, error) {
+	var out []*SystYield
+	for _, id := range sortedSystIDs(m.Lib.Systs) {
+		y, err := m.Syst(id, l)
+		if err != nil {
+			return nil, err
+		}
+		if y.Asteroids > 0 {
+			out = append(out, y)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].TonsPerMinute != out[j].TonsPerMinute {
+			return out[i].TonsPerMinute > out[j].TonsPerMinute
+		}
+		return out[i].Field.Tons() > out[j].Field.Tons()
+	})
+
+	return out, nil
+}
+
+// DPS returns the loadout's damage per second against asteroids, with every gun firing as fast as it reloads.
+// Ammunition limits are ignored.
+func (m *MiningModel) DPS(l *Loadout) (float64, error) {
+	guns, _, err := l.Weapons(m.Lib)
+	if err != nil {
+		return 0, err
+	}
+
+	dps := 0.0
+	for id, n := range guns {
+		w := m.Lib.Weaps[id]
+
+		mass := float64(w.MassDmg)
+		if w.Flags.TenfoldDamageToAsteroids {
+			mass *= 10
+		}
+
+		reload := math.Max(float64(w.Reload), 1)
+		dps += (mass + float64(w.EnergyDmg)/2) * float64(n) * combatFramesPerSecond / reload
+	}
+
+	return dps, nil
+}
+
+func (m *MiningModel) collected(l *Loadout) (float64, error) {
+	for id, n := range l.Outfits {
+		o, ok := m.Lib.Outfs[id]
+		if !ok {
+			return 0, fmt.Errorf("unknown oütf %d", id)
+		}
+		if n <= 0 {
+			continue
+		}
+
+		for _, mod := range o.ModType {
+			if mod.OutfModType() == OutfModTypeMiningScoop {
+				return 1, nil
+			}
+		}
+	}
+
+	return m.WithoutScoop, nil
+}
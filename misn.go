@@ -102,13 +102,13 @@ type Payment int32
 
 type Misn struct {
 	ID            MisnID
-	AvailStel     int16
+	AvailStel     StellarSelector
 	AvailLoc      MisnAvailLoc
 	AvailRecord   int16
 	AvailRating   int16
 	AvailRandom   int16
-	TravelStel    int16
-	ReturnStel    int16
+	TravelStel    StellarSelector
+	ReturnStel    StellarSelector
 	CargoType     int16
 	CargoQty      int16
 	PickupMode    MisnPickupGoal
@@ -116,7 +116,7 @@ type Misn struct {
 	ScanMask      FlagMask16
 	PayVal        Payment
 	ShipCount     int16
-	ShipSyst      int16
+	ShipSyst      SystemSelector
 	ShipDude      DudeID
 	ShipGoal      MisnShipGoal
 	ShipBehav     MisnShipBehav
@@ -135,7 +135,7 @@ type Misn struct {
 	TimeLimit     int16
 	AuxShipCount  int16
 	AuxShipDude   DudeID
-	AuxShipSyst   int16
+	AuxShipSyst   SystemSelector
 	Flags         MisnFlags
 	AvailShipType int16
 	RefuseText    DescID
@@ -167,13 +167,13 @@ func MisnFromBytes(id MisnID, b []byte) *Misn {
 
 	t := &Misn{
 		ID:            id,
-		AvailStel:     int16(binary.BigEndian.Uint16(b[0:])),
+		AvailStel:     ParseStellarSelector(int16(binary.BigEndian.Uint16(b[0:]))),
 		AvailLoc:      MisnAvailLoc(binary.BigEndian.Uint16(b[4:])),
 		AvailRecord:   int16(binary.BigEndian.Uint16(b[6:])),
 		AvailRating:   int16(binary.BigEndian.Uint16(b[8:])),
 		AvailRandom:   int16(binary.BigEndian.Uint16(b[10:])),
-		TravelStel:    int16(binary.BigEndian.Uint16(b[12:])),
-		ReturnStel:    int16(binary.BigEndian.Uint16(b[14:])),
+		TravelStel:    ParseStellarSelector(int16(binary.BigEndian.Uint16(b[12:]))),
+		ReturnStel:    ParseStellarSelector(int16(binary.BigEndian.Uint16(b[14:]))),
 		CargoType:     int16(binary.BigEndian.Uint16(b[16:])),
 		CargoQty:      int16(binary.BigEndian.Uint16(b[18:])),
 		PickupMode:    MisnPickupGoal(binary.BigEndian.Uint16(b[20:])),
@@ -181,7 +181,7 @@ func MisnFromBytes(id MisnID, b []byte) *Misn {
 		ScanMask:      FlagMask16(binary.BigEndian.Uint16(b[24:])),
 		PayVal:        Payment(binary.BigEndian.Uint32(b[30:])),
 		ShipCount:     int16(binary.BigEndian.Uint16(b[32:])),
-		ShipSyst:      int16(binary.BigEndian.Uint16(b[34:])),
+		ShipSyst:      ParseSystemSelector(int16(binary.BigEndian.Uint16(b[34:]))),
 		ShipDude:      DudeID(binary.BigEndian.Uint16(b[36:])),
 		ShipGoal:      MisnShipGoal(binary.BigEndian.Uint16(b[38:])),
 		ShipBehav:     MisnShipBehav(binary.BigEndian.Uint16(b[40:])),
@@ -200,7 +200,7 @@ func MisnFromBytes(id MisnID, b []byte) *Misn {
 		ShipDoneText:  DescID(binary.BigEndian.Uint16(b[68:])),
 		AuxShipCount:  int16(binary.BigEndian.Uint16(b[72:])),
 		AuxShipDude:   DudeID(binary.BigEndian.Uint16(b[74:])),
-		AuxShipSyst:   int16(binary.BigEndian.Uint16(b[76:])),
+		AuxShipSyst:   ParseSystemSelector(int16(binary.BigEndian.Uint16(b[76:]))),
 		Flags: MisnFlags{
 			CanAbort:                    int16(binary.BigEndian.Uint16(b[66:])) == 1,
 			AutoAbort:                   flags&0x0001 == 0x0001,
@@ -0,0 +1,232 @@
+package resources
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Whenever the player lands, Nova walks every mïsn resource and offers the ones whose availability criteria are all
+// met. MisnOracle reproduces that check and, for missions that aren't offered, reports the first criterion that
+// excluded them.
+
+type MisnCriterion int8
+
+const (
+	MisnCriterionNone          MisnCriterion = iota // The mission is available.
+	MisnCriterionAvailLoc                           // The spöb doesn't have the place the mission is offered from.
+	MisnCriterionAvailStel                          // The mission isn't offered at this spöb.
+	MisnCriterionAvailRecord                        // The player's legal record in the system is wrong.
+	MisnCriterionAvailRating                        // The player's combat rating is too low.
+	MisnCriterionAvailShipType                      // The player is flying the wrong ship.
+	MisnCriterionShipClass                          // The mission is unavailable to freighters or warships.
+	MisnCriterionRequire                            // The player's contribute bits don't satisfy Require.
+	MisnCriterionAvailBits                          // AvailBits evaluated to false.
+	MisnCriterionCargoSpace                         // Not enough free cargo space for the mission cargo.
+	MisnCriterionFuel                               // Less than 100 units of fuel for a fuel-taking auto-abort mission.
+	MisnCriterionAvailRandom                        // The AvailRandom roll failed.
+)
+
+func (c MisnCriterion) String() string {
+	switch c {
+	case MisnCriterionAvailLoc:
+		return "AvailLoc"
+	case MisnCriterionAvailStel:
+		return "AvailStel"
+	case MisnCriterionAvailRecord:
+		return "AvailRecord"
+	case MisnCriterionAvailRating:
+		return "AvailRating"
+	case MisnCriterionAvailShipType:
+		return "AvailShipType"
+	case MisnCriterionShipClass:
+		return "ShipClass"
+	case MisnCriterionRequire:
+		return "Require"
+	case MisnCriterionAvailBits:
+		return "AvailBits"
+	case MisnCriterionCargoSpace:
+		return "CargoSpace"
+	case MisnCriterionFuel:
+		return "Fuel"
+	case MisnCriterionAvailRandom:
+		return "AvailRandom"
+	default:
+		return "None"
+	}
+}
+
+type MisnVerdict struct {
+	Misn      MisnID
+	Available bool
+	Failed    MisnCriterion
+	Reason    string
+	Chance    float64 // Probability of the mission being offered once every deterministic criterion has passed.
+}
+
+type MisnOracle struct {
+	Lib        *ResourceLibrary
+	Relations  *GovtRelations
+	Contribute FlagMask64 // Extra contribute bits, e.g. from running crons.
+
+	// Rand is used for the AvailRandom roll. If nil, the roll is skipped and only reported through Chance.
+	Rand *rand.Rand
+}
+
+func NewMisnOracle(lib *ResourceLibrary) *MisnOracle {
+	return &MisnOracle{
+		Lib:       lib,
+		Relations: NewGovtRelations(lib.Govts),
+	}
+}
+
+// Evaluate checks every mission against the pilot landed on the spöb, in mission ID order.
+func (o *MisnOracle) Evaluate(p *NpiL, spob *Spob) []MisnVerdict {
+	ids := sortedMisnIDs(o.Lib.Misns)
+	out := make([]MisnVerdict, len(ids))
+	for i, id := range ids {
+		out[i] = o.Check(p, spob, o.Lib.Misns[id])
+	}
+
+	return out
+}
+
+// Offered returns the missions that would be offered, ordered the way Nova lists them: by descending DispWeight,
+// then by ID.
+func (o *MisnOracle) Offered(p *NpiL, spob *Spob) []*Misn {
+	var out []*Misn
+	for _, v := range o.Evaluate(p, spob) {
+		if v.Available {
+			out = append(out, o.Lib.Misns[v.Misn])
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DispWeight > out[j].DispWeight })
+
+	return out
+}
+
+func misnFail(m *Misn, c MisnCriterion, format string, args ...interface{}) MisnVerdict {
+	return MisnVerdict{Misn: m.ID, Failed: c, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (o *MisnOracle) Check(p *NpiL, spob *Spob, m *Misn) MisnVerdict {
+	syst := o.Lib.SystOfSpob(spob.ID)
+
+	if ok, why := availLocMatches(m.AvailLoc, spob); !ok {
+		return misnFail(m, MisnCriterionAvailLoc, "%s", why)
+	}
+
+	if !m.AvailStel.Matches(o.Lib, &SelectorState{Relations: o.Relations, AvailStel: spob.ID}, spob) {
+		return misnFail(m, MisnCriterionAvailStel, "AvailStel is %s, not spöb %d", m.AvailStel, spob.ID)
+	}
+
+	if m.AvailRecord != 0 {
+		record := int16(0)
+		if syst != nil {
+			record = p.LegalStatus.Get(syst.ID)
+		}
+
+		if m.AvailRecord > 0 && record < m.AvailRecord {
+			return misnFail(m, MisnCriterionAvailRecord, "legal record %d is below %d", record, m.AvailRecord)
+		}
+		if m.AvailRecord < 0 && record > m.AvailRecord {
+			return misnFail(m, MisnCriterionAvailRecord, "legal record %d is above %d", record, m.AvailRecord)
+		}
+	}
+
+	if m.AvailRating > 0 && p.CombatRating < m.AvailRating {
+		return misnFail(m, MisnCriterionAvailRating, "combat rating %d is below %d", p.CombatRating, m.AvailRating)
+	}
+
+	// 128-895 requires that ship type, 1128-1895 excludes it.
+	switch st := m.AvailShipType; {
+	case 128 <= st && st <= 895:
+		if p.ShipClass != ShipID(st) {
+			return misnFail(m, MisnCriterionAvailShipType, "requires ship %d, flying %d", st, p.ShipClass)
+		}
+	case 1128 <= st && st <= 1895:
+		if p.ShipClass == ShipID(st-1000) {
+			return misnFail(m, MisnCriterionAvailShipType, "unavailable in ship %d", st-1000)
+		}
+	}
+
+	if ship, ok := o.Lib.Ships[p.ShipClass]; ok {
+		ai := ship.InherentAI
+		if m.Flags.UnavailableForFreighters && (ai == AITypeWimpyTrader || ai == AITypeBraveTrader) {
+			return misnFail(m, MisnCriterionShipClass, "unavailable for freighters")
+		}
+		if m.Flags.UnavailableForWarships && (ai == AITypeWarship || ai == AITypeInterceptor) {
+			return misnFail(m, MisnCriterionShipClass, "unavailable for warships")
+		}
+	}
+
+	if m.Require != 0 {
+		c := p.Contribute(o.Lib) | o.Contribute
+		if m.Require&c != m.Require {
+			return misnFail(m, MisnCriterionRequire, "missing contribute bits %#016x", uint64(m.Require&^c))
+		}
+	}
+
+	if ok, err := m.AvailBits.Eval(p); err != nil {
+		return misnFail(m, MisnCriterionAvailBits, "AvailBits %q: %v", m.AvailBits, err)
+	} else if !ok {
+		return misnFail(m, MisnCriterionAvailBits, "AvailBits %q is false", m.AvailBits)
+	}
+
+	if m.Flags.UnavailableIfNotEnoughSpace && m.CargoType >= 0 {
+		// Negative quantities are random, up to the absolute value. Assume the worst case.
+		need := int(m.CargoQty)
+		if need < 0 {
+			need = -need
+		}
+
+		free := p.CargoCapacity(o.Lib) - p.CargoUsed()
+		if free < need {
+			return misnFail(m, MisnCriterionCargoSpace, "needs %d tons of cargo space, has %d", need, free)
+		}
+	}
+
+	if m.Flags.Take100FuelOnAutoAbort && p.Fuel < 100 {
+		return misnFail(m, MisnCriterionFuel, "needs 100 units of fuel, has %d", p.Fuel)
+	}
+
+	v := MisnVerdict{Misn: m.ID, Available: true, Chance: 1}
+	if m.AvailRandom < 100 {
+		v.Chance = float64(m.AvailRandom) / 100
+		if v.Chance < 0 {
+			v.Chance = 0
+		}
+
+		if o.Rand != nil && o.Rand.Intn(100) >= int(m.AvailRandom) {
+			v.Available = false
+			v.Failed = MisnCriterionAvailRandom
+			v.Reason = fmt.Sprintf("failed %d%% AvailRandom roll", m.AvailRandom)
+		}
+	}
+
+	return v
+}
+
+func availLocMatches(loc MisnAvailLoc, spob *Spob) (bool, string) {
+	if !spob.Flags.CanLand {
+		return false, "can't land on spöb"
+	}
+
+	switch loc {
+	case MisnAvailLocMissionComputer, MisnAvailLocMainSpaceport:
+		return true, ""
+	case MisnAvailLocBar:
+		return spob.Flags.HasBar, "spöb has no bar"
+	case MisnAvailLocShip:
+		return false, "mission is offered by a ship, not at a spöb"
+	case MisnAvailLocTrading:
+		return spob.Flags.HasCommodityExchange, "spöb has no commodity exchange"
+	case MisnAvailLocShipyard:
+		return spob.Flags.HasShipyard, "spöb has no shipyard"
+	case MisnAvailLocOutfit:
+		return spob.Flags.HasOutfitter, "spöb has no outfitter"
+	}
+
+	return false, fmt.Sprintf("unknown AvailLoc %d", loc)
+}
@@ -0,0 +1,417 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// Nova Control Bits (ncb) are 10,000 global boolean flags that drive missions, crons, fleets and most other
+// conditional content. Test strings are boolean expressions built from the following operators:
+//
+//  bXXX    True if control bit XXX is set.
+//  !       Logical not.
+//  &       Logical and.
+//  |       Logical or.
+//  ( )     Grouping.
+//  G       True if the player is male.
+//  P[XXX]  True if the player has registered (or is within XXX days of the first play).
+//  oXXX    True if the player has at least one of outfit XXX.
+//  eXXX    True if the player has explored system XXX.
+//
+// An empty test string always evaluates to true.
+
+const ControlBitCount = 10000
+
+type ControlBitState interface {
+	Bit(index int) bool
+}
+
+// ControlBitPilot is implemented by states that also know about the player, for the tests that go beyond plain bits.
+// States that don't implement it evaluate those tests as false.
+type ControlBitPilot interface {
+	ControlBitState
+	Male() bool
+	Registered() bool
+	HasOutfit(id OutfID) bool
+	Explored(id SystID) bool
+}
+
+// ControlBits is a standalone set of control bits, for use when there's no pilot involved.
+type ControlBits [ControlBitCount]bool
+
+func (c *ControlBits) Bit(index int) bool {
+	if index < 0 || index >= len(c) {
+		return false
+	}
+
+	return c[index]
+}
+
+func (c *ControlBits) SetBit(index int, value bool) {
+	if index < 0 || index >= len(c) {
+		return
+	}
+
+	c[index] = value
+}
+
+var ErrControlBitSyntax = errors.New("malformed control bit expression")
+
+func (t ControlBitTest) Eval(state ControlBitState) (bool, error) {
+	p := &ncbParser{s: string(t), state: state}
+	p.skipSpace()
+	if p.done() {
+		return true, nil
+	}
+
+	v, err := p.expr()
+	if err != nil {
+		return false, err
+	}
+
+	p.skipSpace()
+	if !p.done() {
+		return false, p.errorf("unexpected %q", p.s[p.pos])
+	}
+
+	return v, nil
+}
+
+// Test is Eval with malformed expressions treated as false, which is how Nova itself behaves.
+func (t ControlBitTest) Test(state ControlBitState) bool {
+	v, err := t.Eval(state)
+	return err == nil && v
+}
+
+// Bits returns the control bits the test reads, in the order they appear.
+func (t ControlBitTest) Bits() ([]int, error) {
+	rec := &controlBitRecorder{}
+	_, err := t.Eval(rec)
+
+	return rec.bits, err
+}
+
+// controlBitRecorder is a state that records which bits are read. Every operand of a test is evaluated, so none are
+// missed.
+type controlBitRecorder struct {
+	bits []int
+}
+
+func (r *controlBitRecorder) Bit(index int) bool {
+	r.bits = append(r.bits, index)
+	return false
+}
+
+type ncbParser struct {
+	s     string
+	pos   int
+	state ControlBitState
+}
+
+func (p *ncbParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *ncbParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at offset %d in %q: %s", ErrControlBitSyntax, p.pos, p.s, fmt.Sprintf(format, args...))
+}
+
+func (p *ncbParser) skipSpace() {
+	for !p.done() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\r' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *ncbParser) expr() (bool, error) {
+	v, err := p.term()
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		p.skipSpace()
+		if p.done() || p.s[p.pos] != '|' {
+			return v, nil
+		}
+		p.pos++
+
+		r, err := p.term()
+		if err != nil {
+			return false, err
+		}
+		v = v || r
+	}
+}
+
+func (p *ncbParser) term() (bool, error) {
+	v, err := p.factor()
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		p.skipSpace()
+		if p.done() || p.s[p.pos] != '&' {
+			return v, nil
+		}
+		p.pos++
+
+		r, err := p.factor()
+		if err != nil {
+			return false, err
+		}
+		v = v && r
+	}
+}
+
+func (p *ncbParser) factor() (bool, error) {
+	p.skipSpace()
+	if p.done() {
+		return false, p.errorf("unexpected end of expression")
+	}
+
+	switch c := p.s[p.pos]; c {
+	case '!':
+		p.pos++
+		v, err := p.factor()
+		return !v, err
+
+	case '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return false, err
+		}
+		p.skipSpace()
+		if p.done() || p.s[p.pos] != ')' {
+			return false, p.errorf("missing ')'")
+		}
+		p.pos++
+		return v, nil
+
+	case 'b', 'B':
+		p.pos++
+		n, err := p.number(true)
+		if err != nil {
+			return false, err
+		}
+		return p.state.Bit(n), nil
+
+	case 'g', 'G':
+		p.pos++
+		if pilot, ok := p.state.(ControlBitPilot); ok {
+			return pilot.Male(), nil
+		}
+		return false, nil
+
+	case 'p', 'P':
+		p.pos++
+		if _, err := p.number(false); err != nil {
+			return false, err
+		}
+		if pilot, ok := p.state.(ControlBitPilot); ok {
+			return pilot.Registered(), nil
+		}
+		return false, nil
+
+	case 'o', 'O':
+		p.pos++
+		n, err := p.number(true)
+		if err != nil {
+			return false, err
+		}
+		if pilot, ok := p.state.(ControlBitPilot); ok {
+			return pilot.HasOutfit(OutfID(n)), nil
+		}
+		return false, nil
+
+	case 'e', 'E':
+		p.pos++
+		n, err := p.number(true)
+		if err != nil {
+			return false, err
+		}
+		if pilot, ok := p.state.(ControlBitPilot); ok {
+			return pilot.Explored(SystID(n)), nil
+		}
+		return false, nil
+
+	default:
+		return false, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *ncbParser) number(required bool) (int, error) {
+	start := p.pos
+	for !p.done() && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+
+	if start == p.pos {
+		if required {
+			return 0, p.errorf("expected a number")
+		}
+		return 0, nil
+	}
+
+	return strconv.Atoi(p.s[start:p.pos])
+}
+
+// Set strings are space-separated lists of operations. The bit operations are:
+//
+//  bXXX    Set control bit XXX.
+//  !bXXX   Clear control bit XXX.
+//  ^bXXX   Toggle control bit XXX.
+//  R(A B)  Perform either operation A or operation B, chosen at random.
+//
+// Every other operator is a single upper-case letter followed by a resource ID (e.g. Sxxx starts a mission, Gxxx
+// grants an outfit, Kxxx activates a rank). These are parsed but only applied by callers that understand them.
+
+type ControlBitWriter interface {
+	ControlBitState
+	SetBit(index int, value bool)
+}
+
+type ControlBitOp struct {
+	Op      byte // 'b' (set), '!' (clear), '^' (toggle), 'R' (random) or the upper-case operator letter.
+	Arg     int
+	Choices []ControlBitOp // The two alternatives of an 'R' operation.
+}
+
+func (f ControlBitFunction) Parse() ([]ControlBitOp, error) {
+	p := &ncbParser{s: string(f)}
+
+	var ops []ControlBitOp
+	for {
+		p.skipSpace()
+		if p.done() {
+			return ops, nil
+		}
+
+		op, err := p.setOp()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+}
+
+func (p *ncbParser) setOp() (ControlBitOp, error) {
+	p.skipSpace()
+	if p.done() {
+		return ControlBitOp{}, p.errorf("unexpected end of expression")
+	}
+
+	c := p.s[p.pos]
+	p.pos++
+
+	switch {
+	case c == '!' || c == '^':
+		if p.done() || (p.s[p.pos] != 'b' && p.s[p.pos] != 'B') {
+			return ControlBitOp{}, p.errorf("expected a bit after %q", c)
+		}
+		p.pos++
+		n, err := p.number(true)
+		return ControlBitOp{Op: c, Arg: n}, err
+
+	case c == 'b' || c == 'B':
+		n, err := p.number(true)
+		return ControlBitOp{Op: 'b', Arg: n}, err
+
+	case c == 'R' || c == 'r':
+		p.skipSpace()
+		if p.done() || p.s[p.pos] != '(' {
+			return ControlBitOp{}, p.errorf("expected '(' after R")
+		}
+		p.pos++
+
+		a, err := p.setOp()
+		if err != nil {
+			return ControlBitOp{}, err
+		}
+		b, err := p.setOp()
+		if err != nil {
+			return ControlBitOp{}, err
+		}
+
+		p.skipSpace()
+		if p.done() || p.s[p.pos] != ')' {
+			return ControlBitOp{}, p.errorf("missing ')'")
+		}
+		p.pos++
+		return ControlBitOp{Op: 'R', Choices: []ControlBitOp{a, b}}, nil
+
+	case 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+		n, err := p.number(true)
+		return ControlBitOp{Op: c &^ 0x20, Arg: n}, err
+	}
+
+	return ControlBitOp{}, p.errorf("unexpected %q", c)
+}
+
+// Apply performs the bit operations of the set string on state. Operators other than bit operations are ignored.
+// Random choices are made with rng, or always take the first alternative if rng is nil.
+func (f ControlBitFunction) Apply(state ControlBitWriter, rng *rand.Rand) error {
+	ops, err := f.Parse()
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		applyControlBitOp(op, state, rng)
+	}
+
+	return nil
+}
+
+// Bits returns the control bits the set string can change, including both alternatives of random choices, in the
+// order they appear.
+func (f ControlBitFunction) Bits() ([]int, error) {
+	ops, err := f.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var bits []int
+	var walk func(ops []ControlBitOp)
+	walk = func(ops []ControlBitOp) {
+		for _, op := range ops {
+			switch op.Op {
+			case 'b', '!', '^':
+				bits = append(bits, op.Arg)
+			case 'R':
+				walk(op.Choices)
+			}
+		}
+	}
+	walk(ops)
+
+	return bits, nil
+}
+
+func applyControlBitOp(op ControlBitOp, state ControlBitWriter, rng *rand.Rand) {
+	switch op.Op {
+	case 'b':
+		state.SetBit(op.Arg, true)
+	case '!':
+		state.SetBit(op.Arg, false)
+	case '^':
+		state.SetBit(op.Arg, !state.Bit(op.Arg))
+	case 'R':
+		i := 0
+		if rng != nil {
+			i = rng.Intn(len(op.Choices))
+		}
+		applyControlBitOp(op.Choices[i], state, rng)
+	}
+}
+
+// ControlBitHook executes a set string. Simulators accept one so callers can handle the non-bit operators.
+type ControlBitHook func(f ControlBitFunction, state ControlBitWriter, rng *rand.Rand) error
+
+// DefaultControlBitHook applies only the bit operations of a set string.
+func DefaultControlBitHook(f ControlBitFunction, state ControlBitWriter, rng *rand.Rand) error {
+	return f.Apply(state, rng)
+}
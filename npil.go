@@ -13,7 +13,16 @@ type MissionData struct {
 	// Not sure what this looks like
 }
 
+type PilotGender int16
+
+const (
+	PilotGenderFemale PilotGender = iota
+	PilotGenderMale
+)
+
 type NpiL struct {
+	Name string // The pilot's name is stored as the name of the NpïL resource, not in its data.
+
 	LastStellar      SpobID
 	ShipClass        ShipID
 	Cargo            [6]int16
@@ -24,7 +33,7 @@ type NpiL struct {
 	Year             int16
 	Exploration      [2048]int16 // SpobID -> (0: unexplored, 1: visited, 2: landed)
 	ItemCount        [512]int16
-	LegalStatus      [2048]int16
+	LegalStatus      LegalRecord
 	WeaponCount      [256]int16
 	Ammo             [256]int16
 	Cash             Credits
@@ -40,7 +49,7 @@ type NpiL struct {
 
 	VersionInfo      int16
 	StrictPlayFlag   int16
-	Gender           int16
+	Gender           PilotGender
 	StellarShipCount [2048]int16
 	PersonAlive      [1024]int16
 	PersonGrudge     [1024]int16
@@ -69,7 +78,10 @@ type NpiL struct {
 }
 
 func PilotFromResource(resource resourcefork.Resource) *NpiL {
-	return PilotFromBytes(resource.Data)
+	t := PilotFromBytes(resource.Data)
+	t.Name = resource.Name
+
+	return t
 }
 
 const EncryptionKey uint32 = 0xb36a210f
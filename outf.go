@@ -117,6 +117,15 @@ func (r RequireGovtID) NotGovtAndAlliesAndIndependent() bool {
 	return 3128 <= r && r <= 3383
 }
 
+// Govt returns the govt the requirement refers to, or GovtIDIndependent for All.
+func (r RequireGovtID) Govt() GovtID {
+	if r < 128 {
+		return GovtIDIndependent
+	}
+
+	return GovtID((r-128)%1000 + 128)
+}
+
 type Outf struct {
 	ID OutfID
 
@@ -43,12 +43,10 @@ type PersFlags struct {
 	StartsWithoutFuel               bool // 0x0001 This person starts with zero fuel.
 }
 
-type PersLinkSyst int16
-
 type Pers struct {
 	ID PersID
 
-	LinkSyst    PersLinkSyst
+	LinkSyst    SystemSelector
 	Govt        GovtID
 	AIType      AIType
 	Aggression  PersAggression
@@ -81,7 +79,7 @@ func PersFromBytes(id PersID, b []byte) *Pers {
 
 	t := &Pers{
 		ID:         id,
-		LinkSyst:   PersLinkSyst(binary.BigEndian.Uint16(b[2:])),
+		LinkSyst:   ParseSystemSelector(int16(binary.BigEndian.Uint16(b[0:]))),
 		Govt:       GovtID(binary.BigEndian.Uint16(b[2:])),
 		AIType:     AIType(binary.BigEndian.Uint16(b[4:])),
 		Aggression: PersAggression(binary.BigEndian.Uint16(b[6:])),
@@ -17,6 +17,8 @@ const (
 type Pict struct {
 	ID    PictID
 	Image *image.NRGBA
+
+	data []byte // The undecoded resource, when the library was loaded lazily.
 }
 
 func PictFromResource(resource resourcefork.Resource) *Pict {
@@ -0,0 +1,269 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// PICT version 2 resources are a list of QuickDraw opcodes. The ones we write are:
+//
+//  0x0011  Version (followed by 0x02FF).
+//  0x0C00  Extended header: version -2, 72 dpi, the source rectangle.
+//  0x001E  Default highlight.
+//  0x0001  Clip region covering the whole picture.
+//  0x009A  DirectBitsRect, 32-bit RGB stored as one plane per component and row.
+//  0x0098  PackBitsRect, 8-bit indices into a colour table.
+//  0x00FF  End of picture.
+//
+// Rows of 8 or more bytes are compressed with PackBits. Opcodes are always word aligned.
+
+const (
+	pictOpClipRegion     = 0x0001
+	pictOpVersion        = 0x0011
+	pictOpDefHilite      = 0x001E
+	pictOpPackBitsRect   = 0x0098
+	pictOpDirectBitsRect = 0x009A
+	pictOpEndOfPicture   = 0x00FF
+	pictOpHeader         = 0x0C00
+	pictResolution       = 72 << 16
+	pictMaxDimension     = 0x7FFF
+)
+
+// EncodePict encodes the image as a 32-bit direct colour PICT. Transparency is dropped; pair the picture with a mask
+// from EncodePictMask where it matters.
+func EncodePict(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	w32, err := pictStart(w, h)
+	if err != nil {
+		return nil, err
+	}
+
+	rowBytes := w * 4
+	be := binary.BigEndian
+
+	_ = binary.Write(w32, be, uint16(pictOpDirectBitsRect))
+	_ = binary.Write(w32, be, uint32(0x000000FF)) // Base address, unused in pictures
+	pictPixMap(w32, w, h, rowBytes, 4, 16, 32, 3, 8)
+	pictRects(w32, w, h)
+
+	// Packed rows hold one plane per component. Rows too narrow to pack hold the pixels as they are, xRGB.
+	row := make([]byte, w*3)
+	if rowBytes < 8 {
+		row = make([]byte, rowBytes)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			if rowBytes < 8 {
+				row[4*x+1], row[4*x+2], row[4*x+3] = c.R, c.G, c.B
+			} else {
+				row[x], row[w+x], row[2*w+x] = c.R, c.G, c.B
+			}
+		}
+		pictRow(w32, row, rowBytes)
+	}
+
+	return pictEnd(w32), nil
+}
+
+// EncodePict8 encodes the image as an 8-bit indexed PICT, mapping each pixel to the nearest colour in the palette.
+// A nil palette uses MacPalette8.
+func EncodePict8(img image.Image, palette color.Palette) ([]byte, error) {
+	if palette == nil {
+		palette = MacPalette8
+	}
+	if len(palette) == 0 || len(palette) > 256 {
+		return nil, errors.New("an 8-bit PICT needs between 1 and 256 colours")
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	w8, err := pictStart(w, h)
+	if err != nil {
+		return nil, err
+	}
+
+	// Row bytes must be even.
+	rowBytes := (w + 1) &^ 1
+	be := binary.BigEndian
+
+	_ = binary.Write(w8, be, uint16(pictOpPackBitsRect))
+	pictPixMap(w8, w, h, rowBytes, 0, 0, 8, 1, 8)
+
+	_ = binary.Write(w8, be, uint32(0))              // Seed
+	_ = binary.Write(w8, be, uint16(0))              // Flags
+	_ = binary.Write(w8, be, uint16(len(palette)-1)) // Size - 1
+	for i, c := range palette {
+		r, g, b, _ := c.RGBA()
+		_ = binary.Write(w8, be, []uint16{uint16(i), uint16(r), uint16(g), uint16(b)})
+	}
+	pictRects(w8, w, h)
+
+	row := make([]byte, rowBytes)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			row[x] = byte(palette.Index(img.At(b.Min.X+x, b.Min.Y+y)))
+		}
+		pictRow(w8, row, rowBytes)
+	}
+
+	return pictEnd(w8), nil
+}
+
+// EncodePictMask encodes a sprite mask: white where the image is at least half opaque, black elsewhere.
+func EncodePictMask(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	mask := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if _, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA(); a >= 0x8000 {
+				mask.SetGray(x, y, color.Gray{Y: 0xFF})
+			}
+		}
+	}
+
+	return EncodePict(mask)
+}
+
+// EncodeSpinPicts lays the frames out in a grid the given number of frames across and encodes the sprite and mask
+// PICTs a spïn resource points to. Every frame must be the same size.
+func EncodeSpinPicts(frames []image.Image, across int) (sprites []byte, masks []byte, err error) {
+	if len(frames) == 0 || across <= 0 {
+		return nil, nil, errors.New("a sprite sheet needs at least one frame and one column")
+	}
+
+	size := frames[0].Bounds().Size()
+	down := (len(frames) + across - 1) / across
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, size.X*across, size.Y*down))
+	for i, f := range frames {
+		if f.Bounds().Size() != size {
+			return nil, nil, errors.New("every frame of a sprite sheet must be the same size")
+		}
+
+		at := image.Pt(i%across*size.X, i/across*size.Y)
+		draw.Draw(sheet, image.Rectangle{Min: at, Max: at.Add(size)}, f, f.Bounds().Min, draw.Src)
+	}
+
+	if sprites, err = EncodePict(sheet); err != nil {
+		return nil, nil, err
+	}
+	if masks, err = EncodePictMask(sheet); err != nil {
+		return nil, nil, err
+	}
+
+	return sprites, masks, nil
+}
+
+func pictStart(w, h int) (*bytes.Buffer, error) {
+	if w <= 0 || h <= 0 || w > pictMaxDimension || h > pictMaxDimension {
+		return nil, errors.New("PICT dimensions out of range")
+	}
+
+	out := &bytes.Buffer{}
+	be := binary.BigEndian
+
+	_ = binary.Write(out, be, uint16(0)) // Size, filled in by pictEnd
+	_ = binary.Write(out, be, []int16{0, 0, int16(h), int16(w)})
+	_ = binary.Write(out, be, []uint16{pictOpVersion, 0x02FF})
+
+	_ = binary.Write(out, be, uint16(pictOpHeader))
+	_ = binary.Write(out, be, []uint16{0xFFFE, 0})
+	_ = binary.Write(out, be, []uint32{pictResolution, pictResolution})
+	_ = binary.Write(out, be, []int16{0, 0, int16(h), int16(w)})
+	_ = binary.Write(out, be, uint32(0))
+
+	_ = binary.Write(out, be, uint16(pictOpDefHilite))
+	_ = binary.Write(out, be, uint16(pictOpClipRegion))
+	_ = binary.Write(out, be, []int16{10, 0, 0, int16(h), int16(w)})
+
+	return out, nil
+}
+
+func pictPixMap(out *bytes.Buffer, w, h, rowBytes, packType, pixelType, pixelSize, cmpCount, cmpSize int) {
+	be := binary.BigEndian
+
+	_ = binary.Write(out, be, uint16(rowBytes|0x8000))
+	_ = binary.Write(out, be, []int16{0, 0, int16(h), int16(w)})
+	_ = binary.Write(out, be, uint16(0)) // Version
+	_ = binary.Write(out, be, uint16(packType))
+	_ = binary.Write(out, be, uint32(0)) // Pack size
+	_ = binary.Write(out, be, []uint32{pictResolution, pictResolution})
+	_ = binary.Write(out, be, []uint16{uint16(pixelType), uint16(pixelSize), uint16(cmpCount), uint16(cmpSize)})
+	_ = binary.Write(out, be, []uint32{0, 0, 0}) // Plane bytes, colour table, reserved
+}
+
+func pictRects(out *bytes.Buffer, w, h int) {
+	be := binary.BigEndian
+
+	_ = binary.Write(out, be, []int16{0, 0, int16(h), int16(w)}) // Source
+	_ = binary.Write(out, be, []int16{0, 0, int16(h), int16(w)}) // Destination
+	_ = binary.Write(out, be, uint16(0))                         // srcCopy
+}
+
+func pictRow(out *bytes.Buffer, row []byte, rowBytes int) {
+	if rowBytes < 8 {
+		out.Write(row)
+		return
+	}
+
+	packed := packBits(row)
+	if rowBytes > 250 {
+		_ = binary.Write(out, binary.BigEndian, uint16(len(packed)))
+	} else {
+		out.WriteByte(byte(len(packed)))
+	}
+	out.Write(packed)
+}
+
+func pictEnd(out *bytes.Buffer) []byte {
+	if out.Len()%2 != 0 {
+		out.WriteByte(0)
+	}
+	_ = binary.Write(out, binary.BigEndian, uint16(pictOpEndOfPicture))
+
+	b := out.Bytes()
+	binary.BigEndian.PutUint16(b, uint16(len(b)))
+
+	return b
+}
+
+// packBits compresses bytes with Apple's PackBits: a header byte n of 0 to 127 is followed by n+1 literal bytes, and
+// a negative header n of -1 to -127 is followed by one byte repeated 1-n times.
+func packBits(b []byte) []byte {
+	var out []byte
+
+	for i := 0; i < len(b); {
+		run := 1
+		for i+run < len(b) && run < 128 && b[i+run] == b[i] {
+			run++
+		}
+
+		if run >= 3 {
+			out = append(out, byte(257-run), b[i])
+			i += run
+			continue
+		}
+
+		start := i
+		for i < len(b) && i-start < 128 {
+			if i+2 < len(b) && b[i] == b[i+1] && b[i] == b[i+2] {
+				break
+			}
+			i++
+		}
+		out = append(out, byte(i-start-1))
+		out = append(out, b[start:i]...)
+	}
+
+	return out
+}
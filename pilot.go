@@ -0,0 +1,422 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/imle/resourcefork"
+)
+
+// Most of the per-resource arrays in a pilot are indexed by resource ID - 128.
+func pilotIndex(id IDType, size int) int {
+	i := int(id) - resourcefork.ResourceForkIDOffset
+	if i < 0 || i >= size {
+		return -1
+	}
+
+	return i
+}
+
+func (p *NpiL) Bit(index int) bool {
+	if index < 0 || index >= len(p.MissionBits) {
+		return false
+	}
+
+	return p.MissionBits[index] != 0
+}
+
+func (p *NpiL) SetBit(index int, value bool) {
+	if index < 0 || index >= len(p.MissionBits) {
+		return
+	}
+
+	if value {
+		p.MissionBits[index] = 1
+	} else {
+		p.MissionBits[index] = 0
+	}
+}
+
+func (p *NpiL) Male() bool {
+	return p.Gender == PilotGenderMale
+}
+
+// Registered always reports true, as Nova no longer requires registration.
+func (p *NpiL) Registered() bool {
+	return true
+}
+
+// Nickname returns the pilot's ship's nickname, or an empty string if it hasn't been given one.
+func (p *NpiL) Nickname() string {
+	n := int(p.NicknameLength)
+	if n < 0 || n > len(p.NickName) {
+		n = len(p.NickName)
+	}
+
+	return decodeMacRoman(p.NickName[:n])
+}
+
+// Date returns the pilot's game date as Nova displays it, with the scenario's DatePrefix and DateSuffix.
+func (p *NpiL) Date() string {
+	month := time.Month(p.Month).String()
+	if p.Month < 1 || p.Month > 12 {
+		month = fmt.Sprintf("Month %d", p.Month)
+	}
+
+	return fmt.Sprintf("%s%s %d, %d%s", pilotString(p.DatePrefix[:]), month, p.Day, p.Year, pilotString(p.DateSuffix[:]))
+}
+
+// pilotString decodes a NUL-padded string, which may fill the whole field.
+func pilotString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	return decodeMacRoman(b)
+}
+
+func (p *NpiL) HasOutfit(id OutfID) bool {
+	return p.OutfitCount(id) > 0
+}
+
+func (p *NpiL) OutfitCount(id OutfID) int16 {
+	if i := pilotIndex(IDType(id), len(p.ItemCount)); i >= 0 {
+		return p.ItemCount[i]
+	}
+
+	return 0
+}
+
+func (p *NpiL) Explored(id SystID) bool {
+	if i := pilotIndex(IDType(id), len(p.Exploration)); i >= 0 {
+		return p.Exploration[i] > 0
+	}
+
+	return false
+}
+
+func (p *NpiL) RankIsActive(id RankID) bool {
+	if i := pilotIndex(IDType(id), len(p.RankActive)); i >= 0 {
+		return p.RankActive[i] != 0
+	}
+
+	return false
+}
+
+func (p *NpiL) SetRankActive(id RankID, active bool) {
+	i := pilotIndex(IDType(id), len(p.RankActive))
+	if i < 0 {
+		return
+	}
+
+	if active {
+		p.RankActive[i] = 1
+	} else {
+		p.RankActive[i] = 0
+	}
+}
+
+// AddOutfit adjusts the pilot's count of an outfit, along with the weapons and ammunition it provides.
+func (p *NpiL) AddOutfit(lib *ResourceLibrary, id OutfID, count int16) {
+	i := pilotIndex(IDType(id), len(p.ItemCount))
+	if i < 0 {
+		return
+	}
+
+	if p.ItemCount[i]+count < 0 {
+		count = -p.ItemCount[i]
+	}
+	p.ItemCount[i] += count
+
+	o, ok := lib.Outfs[id]
+	if !ok {
+		return
+	}
+
+	for _, mod := range o.ModType {
+		switch mod.OutfModType() {
+		case OutfModTypeWeapon:
+			p.addWeapon(WeapID(mod.OutfModValue()), count, 0)
+		case OutfModTypeAmmunition:
+			p.addWeapon(WeapID(mod.OutfModValue()), 0, count)
+		}
+	}
+}
+
+func (p *NpiL) addWeapon(id WeapID, count int16, ammo int16) {
+	i := pilotIndex(IDType(id), len(p.WeaponCount))
+	if i < 0 {
+		return
+	}
+
+	p.WeaponCount[i] += count
+	if p.WeaponCount[i] < 0 {
+		p.WeaponCount[i] = 0
+	}
+	p.Ammo[i] += ammo
+	if p.Ammo[i] < 0 {
+		p.Ammo[i] = 0
+	}
+}
+
+func (p *NpiL) setExplored(id SystID, level int16) {
+	if i := pilotIndex(IDType(id), len(p.Exploration)); i >= 0 {
+		p.Exploration[i] = level
+	}
+}
+
+// changeShip swaps the pilot's ship for another class, along with its built-in weapons and fuel. Unknown ships are
+// ignored.
+func (p *NpiL) changeShip(lib *ResourceLibrary, id ShipID) {
+	ship, ok := lib.Ships[id]
+	if !ok {
+		return
+	}
+
+	if old, ok := lib.Ships[p.ShipClass]; ok {
+		for i, w := range old.WeapType {
+			if w > 0 {
+				p.addWeapon(w, -old.WeapCount[i], -old.AmmoLoad[i])
+			}
+		}
+	}
+	for i, w := range ship.WeapType {
+		if w > 0 {
+			p.addWeapon(w, ship.WeapCount[i], ship.AmmoLoad[i])
+		}
+	}
+
+	p.ShipClass = id
+	p.Fuel = ship.Fuel
+}
+
+// ApplyControlBits runs a set string against the pilot. Besides the bit operations it handles the operators that
+// only touch the pilot record:
+//
+//	Gxxx  Grant one of outfit xxx.
+//	Dxxx  Remove one of outfit xxx.
+//	Exxx  Change the player's ship to shïp xxx. The old ship's built-in weapons and ammunition are replaced by the
+//	      new ship's and the fuel tank is filled to the new ship's Fuel, but the player keeps their outfits and doesn't
+//	      get the new ship's default items.
+//	Kxxx  Activate rank xxx.
+//	Lxxx  Deactivate rank xxx.
+//	Xxxx  Mark system xxx as explored.
+//
+// Operators that need a running game (starting missions, moving the player, etc.) are ignored.
+func (p *NpiL) ApplyControlBits(lib *ResourceLibrary, f ControlBitFunction, rng *rand.Rand) error {
+	ops, err := f.Parse()
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		p.applyControlBitOp(lib, op, rng)
+	}
+
+	return nil
+}
+
+func (p *NpiL) applyControlBitOp(lib *ResourceLibrary, op ControlBitOp, rng *rand.Rand) {
+	switch op.Op {
+	case 'G':
+		p.AddOutfit(lib, OutfID(op.Arg), 1)
+	case 'D':
+		p.AddOutfit(lib, OutfID(op.Arg), -1)
+	case 'E':
+		p.changeShip(lib, ShipID(op.Arg))
+	case 'X':
+		if !p.Explored(SystID(op.Arg)) {
+			p.setExplored(SystID(op.Arg), 1)
+		}
+	case 'K':
+		p.SetRankActive(RankID(op.Arg), true)
+	case 'L':
+		p.SetRankActive(RankID(op.Arg), false)
+	case 'R':
+		i := 0
+		if rng != nil {
+			i = rng.Intn(len(op.Choices))
+		}
+		p.applyControlBitOp(lib, op.Choices[i], rng)
+	default:
+		applyControlBitOp(op, p, rng)
+	}
+}
+
+// PilotControlBitHook returns a hook that applies the pilot-level operators when the state is a pilot, and only the
+// bit operations otherwise.
+func PilotControlBitHook(lib *ResourceLibrary) ControlBitHook {
+	return func(f ControlBitFunction, state ControlBitWriter, rng *rand.Rand) error {
+		if p, ok := state.(*NpiL); ok {
+			return p.ApplyControlBits(lib, f, rng)
+		}
+
+		return f.Apply(state, rng)
+	}
+}
+
+func putByteString(dst []byte, s string) {
+	for i := range dst {
+		dst[i] = 0
+	}
+
+	// Always leave room for the terminating null.
+	copy(dst[:len(dst)-1], s)
+}
+
+// DefaultSystID is where the player starts if a chär resource doesn't list any starting systems.
+const DefaultSystID SystID = 128
+
+// NewPilotFromChar builds the state of a brand new pilot started from the given chär resource, as Nova would when the
+// player creates a new pilot. The seed picks the starting system and resolves any random choices in OnStart. Returns
+// nil if the chär resource doesn't exist.
+func NewPilotFromChar(lib *ResourceLibrary, id CharID, name string, gender PilotGender, seed int64) *NpiL {
+	c, ok := lib.Chars[id]
+	if !ok {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	p := &NpiL{
+		Name:         name,
+		Gender:       gender,
+		Cash:         c.Cash,
+		ShipClass:    c.ShipType,
+		CombatRating: c.CombatRating,
+		Day:          c.StartDate,
+		Month:        c.StartMonth,
+		Year:         c.StartYear,
+		LastStellar:  -1,
+	}
+	putByteString(p.DatePrefix[:], c.DatePrefix)
+	putByteString(p.DateSuffix[:], c.DateSuffix)
+
+	for i := range p.EscortClass {
+		p.EscortClass[i] = -1
+		p.FighterClass[i] = -1
+	}
+
+	// Starting system, picked at random from the ones listed
+	var systs []SystID
+	for _, s := range c.System {
+		if s > 0 {
+			systs = append(systs, s)
+		}
+	}
+
+	start := DefaultSystID
+	if len(systs) > 0 {
+		start = systs[rng.Intn(len(systs))]
+	}
+
+	p.setExplored(start, 1)
+	if syst, ok := lib.Systs[start]; ok {
+		for _, spob := range syst.NavDef {
+			if spob > 0 {
+				p.LastStellar = spob
+				break
+			}
+		}
+	}
+
+	// Starting ship, its built-in weapons and default outfits
+	if ship, ok := lib.Ships[c.ShipType]; ok {
+		p.Fuel = ship.Fuel
+
+		for i, w := range ship.WeapType {
+			if w > 0 {
+				p.addWeapon(w, ship.WeapCount[i], ship.AmmoLoad[i])
+			}
+		}
+
+		for i, o := range ship.DefaultItems {
+			if o > 0 && ship.ItemCount[i] > 0 {
+				p.AddOutfit(lib, o, ship.ItemCount[i])
+			}
+		}
+		for i, o := range ship.DefaultItems2 {
+			if o > 0 && ship.ItemCount2[i] > 0 {
+				p.AddOutfit(lib, o, ship.ItemCount2[i])
+			}
+		}
+	}
+
+	NewLegalEngine(lib).InitFromChar(&p.LegalStatus, c)
+
+	for pid := range lib.Perss {
+		if i := pilotIndex(IDType(pid), len(p.PersonAlive)); i >= 0 {
+			p.PersonAlive[i] = 1
+		}
+	}
+
+	for sid, spob := range lib.Spobs {
+		if i := pilotIndex(IDType(sid), len(p.StellarDestroyed)); i >= 0 && spob.Flags.StartDestroyed {
+			p.StellarDestroyed[i] = 1
+		}
+	}
+
+	// Malformed OnStart strings are skipped, as Nova does.
+	_ = p.ApplyControlBits(lib, c.OnStart, rng)
+
+	return p
+}
+
+// Contribute combines the contribute bits of the pilot's ship, every outfit they carry and their active ranks.
+func (p *NpiL) Contribute(lib *ResourceLibrary) FlagMask64 {
+	var c FlagMask64
+	if ship, ok := lib.Ships[p.ShipClass]; ok {
+		c |= ship.Contribute
+	}
+
+	for id, o := range lib.Outfs {
+		if p.HasOutfit(id) {
+			c |= o.Contribute
+		}
+	}
+
+	for id, r := range lib.Ranks {
+		if p.RankIsActive(id) {
+			c |= r.Contribute
+		}
+	}
+
+	return c
+}
+
+// CargoCapacity returns the total cargo space of the pilot's ship, including any cargo space outfits.
+func (p *NpiL) CargoCapacity(lib *ResourceLibrary) int {
+	total := 0
+	if ship, ok := lib.Ships[p.ShipClass]; ok {
+		total += int(ship.Holds)
+	}
+
+	for id, o := range lib.Outfs {
+		n := int(p.OutfitCount(id))
+		if n <= 0 {
+			continue
+		}
+		for _, mod := range o.ModType {
+			if mod.OutfModType() == OutfModTypeCargoSpace {
+				total += n * int(mod.OutfModValue())
+			}
+		}
+	}
+
+	return total
+}
+
+// CargoUsed returns the tons of commodities and jünk the pilot is carrying.
+func (p *NpiL) CargoUsed() int {
+	total := 0
+	for _, n := range p.Cargo {
+		total += int(n)
+	}
+	for _, n := range p.JunkQuantity {
+		total += int(n)
+	}
+
+	return total
+}
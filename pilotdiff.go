@@ -0,0 +1,298 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imle/resourcefork"
+)
+
+// Diffing two saves of the same pilot shows what a mission script did. Every change is listed with a line of text
+// describing it; changed control bits also name the mïsns whose AvailBits test or set strings use them.
+
+type PilotChangeKind int8
+
+const (
+	PilotChangeBit PilotChangeKind = iota
+	PilotChangeCash
+	PilotChangeShip
+	PilotChangeOutfit
+	PilotChangeLegal
+	PilotChangeExploration
+	PilotChangeEscort
+	PilotChangeCron
+	PilotChangePerson
+)
+
+func (k PilotChangeKind) String() string {
+	switch k {
+	case PilotChangeBit:
+		return "Bit"
+	case PilotChangeCash:
+		return "Cash"
+	case PilotChangeShip:
+		return "Ship"
+	case PilotChangeOutfit:
+		return "Outfit"
+	case PilotChangeLegal:
+		return "Legal"
+	case PilotChangeExploration:
+		return "Exploration"
+	case PilotChangeEscort:
+		return "Escort"
+	case PilotChangeCron:
+		return "Cron"
+	case PilotChangePerson:
+		return "Person"
+	default:
+		return fmt.Sprintf("PilotChangeKind(%d)", k)
+	}
+}
+
+type PilotChange struct {
+	Kind  PilotChangeKind
+	ID    int    // The bit, resource ID or escort slot that changed. Zero for cash; the sÿst for legal status.
+	Field string // For crons, "duration" or "hold-off"; for persons, "alive" or "grudge".
+	From  int
+	To    int
+	Text  string
+}
+
+type PilotDiff struct {
+	Changes []PilotChange
+}
+
+// String returns the changes, one per line.
+func (d *PilotDiff) String() string {
+	b := &strings.Builder{}
+	for _, c := range d.Changes {
+		b.WriteString(c.Text)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// DiffPilots lists what changed from pilot a to pilot b, grouped by kind and in ID order within each kind.
+func DiffPilots(a, b *NpiL, lib *ResourceLibrary) *PilotDiff {
+	d := &PilotDiff{}
+	add := func(kind PilotChangeKind, id int, field string, from, to int, format string, args ...interface{}) {
+		d.Changes = append(d.Changes, PilotChange{
+			Kind:  kind,
+			ID:    id,
+			Field: field,
+			From:  from,
+			To:    to,
+			Text:  fmt.Sprintf(format, args...),
+		})
+	}
+	offset := resourcefork.ResourceForkIDOffset
+
+	refs := misnBitRefs(lib)
+	for i := range a.MissionBits {
+		from, to := a.Bit(i), b.Bit(i)
+		if from == to {
+			continue
+		}
+
+		text := fmt.Sprintf("bit %d cleared", i)
+		if to {
+			text = fmt.Sprintf("bit %d set", i)
+		}
+		if r := refs[i]; len(r) > 0 {
+			text += " (" + strings.Join(r, "; ") + ")"
+		}
+		add(PilotChangeBit, i, "", boolInt(from), boolInt(to), "%s", text)
+	}
+
+	if a.Cash != b.Cash {
+		add(PilotChangeCash, 0, "", int(a.Cash), int(b.Cash), "cash %d -> %d (%+d)", a.Cash, b.Cash, b.Cash-a.Cash)
+	}
+
+	if a.ShipClass != b.ShipClass {
+		add(PilotChangeShip, int(b.ShipClass), "", int(a.ShipClass), int(b.ShipClass), "%s -> %s",
+			pilotShipName(lib, a.ShipClass), pilotShipName(lib, b.ShipClass))
+	}
+
+	for i := range a.ItemCount {
+		from, to := a.ItemCount[i], b.ItemCount[i]
+		if from == to {
+			continue
+		}
+
+		id := OutfID(i + offset)
+		name := fmt.Sprintf("oütf %d", id)
+		if o, ok := lib.Outfs[id]; ok {
+			name = fmt.Sprintf("oütf %d %q", id, outfName(lib, o))
+		}
+		add(PilotChangeOutfit, int(id), "", int(from), int(to), "%s %d -> %d", name, from, to)
+	}
+
+	legal := NewLegalEngine(lib)
+	for i := range a.LegalStatus {
+		from, to := a.LegalStatus[i], b.LegalStatus[i]
+		if from == to {
+			continue
+		}
+
+		id := SystID(i + offset)
+		syst := named(lib, "sÿst", IDType(id))
+		if s, ok := lib.Systs[id]; ok && s.Govt != GovtIDIndependent {
+			syst += " (" + named(lib, "gövt", IDType(s.Govt)) + ")"
+		}
+		add(PilotChangeLegal, int(id), "", int(from), int(to), "%s legal status %d (%s) -> %d (%s), %+d", syst,
+			from, legal.Label(from), to, legal.Label(to), int(to)-int(from))
+	}
+
+	for i := range a.Exploration {
+		from, to := a.Exploration[i], b.Exploration[i]
+		if from != to {
+			add(PilotChangeExploration, i+offset, "", int(from), int(to), "%s %s -> %s",
+				named(lib, "sÿst", IDType(i+offset)), explorationLabel(from), explorationLabel(to))
+		}
+	}
+
+	for i := range a.EscortClass {
+		from, to := a.EscortClass[i], b.EscortClass[i]
+		if from != to {
+			add(PilotChangeEscort, i, "", int(from), int(to), "escort slot %d: %s -> %s", i,
+				escortLabel(lib, from), escortLabel(lib, to))
+		}
+	}
+
+	for i := range a.CronDuration {
+		cron := named(lib, "crön", IDType(i+offset))
+		if from, to := a.CronDuration[i], b.CronDuration[i]; from != to {
+			add(PilotChangeCron, i+offset, "duration", int(from), int(to), "%s duration %d -> %d", cron, from, to)
+		}
+		if from, to := a.CronHoldOff[i], b.CronHoldOff[i]; from != to {
+			add(PilotChangeCron, i+offset, "hold-off", int(from), int(to), "%s hold-off %d -> %d", cron, from, to)
+		}
+	}
+
+	for i := range a.PersonAlive {
+		pers := named(lib, "përs", IDType(i+offset))
+		if from, to := a.PersonAlive[i] != 0, b.PersonAlive[i] != 0; from != to {
+			state := "killed"
+			if to {
+				state = "revived"
+			}
+			add(PilotChangePerson, i+offset, "alive", boolInt(from), boolInt(to), "%s %s", pers, state)
+		}
+		if from, to := a.PersonGrudge[i] != 0, b.PersonGrudge[i] != 0; from != to {
+			state := "no longer holds a grudge"
+			if to {
+				state = "now holds a grudge"
+			}
+			add(PilotChangePerson, i+offset, "grudge", boolInt(from), boolInt(to), "%s %s", pers, state)
+		}
+	}
+
+	return d
+}
+
+// misnBitRefs maps each control bit to the mïsns that use it, with the fields they use it in.
+func misnBitRefs(lib *ResourceLibrary) map[int][]string {
+	type ref struct {
+		misn   MisnID
+		fields []string
+	}
+	refs := map[int][]*ref{}
+
+	for _, id := range sortedMisnIDs(lib.Misns) {
+		m := lib.Misns[id]
+
+		use := func(field string, bits []int, err error) {
+			if err != nil {
+				return
+			}
+			for _, bit := range bits {
+				r := refs[bit]
+				if len(r) == 0 || r[len(r)-1].misn != id {
+					r = append(r, &ref{misn: id})
+					refs[bit] = r
+				}
+				last := r[len(r)-1]
+				if n := len(last.fields); n == 0 || last.fields[n-1] != field {
+					last.fields = append(last.fields, field)
+				}
+			}
+		}
+
+		bits, err := m.AvailBits.Bits()
+		use("AvailBits", bits, err)
+		for _, f := range []struct {
+			name string
+			f    ControlBitFunction
+		}{
+			{"OnAccept", m.OnAccept},
+			{"OnRefuse", m.OnRefuse},
+			{"OnSuccess", m.OnSuccess},
+			{"OnFailure", m.OnFailure},
+			{"OnAbort", m.OnAbort},
+			{"OnShipDone", m.OnShipDone},
+		} {
+			bits, err := f.f.Bits()
+			use(f.name, bits, err)
+		}
+	}
+
+	out := make(map[int][]string, len(refs))
+	for bit, rs := range refs {
+		for _, r := range rs {
+			out[bit] = append(out[bit], fmt.Sprintf("%s: %s", named(lib, "mïsn", IDType(r.misn)), strings.Join(r.fields, ", ")))
+		}
+	}
+
+	return out
+}
+
+// named returns the type and ID of a resource, followed by its name if the library knows it.
+func named(lib *ResourceLibrary, typ string, id IDType) string {
+	if n := lib.ResourceName(typ, id); n != "" {
+		return fmt.Sprintf("%s %d %q", typ, id, n)
+	}
+
+	return fmt.Sprintf("%s %d", typ, id)
+}
+
+func pilotShipName(lib *ResourceLibrary, id ShipID) string {
+	if s, ok := lib.Ships[id]; ok {
+		return fmt.Sprintf("shïp %d %q", id, shipName(lib, s))
+	}
+
+	return fmt.Sprintf("shïp %d", id)
+}
+
+func escortLabel(lib *ResourceLibrary, v ShipID) string {
+	id, hired, ok := decodePilotShip(v)
+	if !ok {
+		return "none"
+	}
+	if hired {
+		return "hired " + pilotShipName(lib, id)
+	}
+
+	return "captured " + pilotShipName(lib, id)
+}
+
+func explorationLabel(v int16) string {
+	switch v {
+	case 0:
+		return "unexplored"
+	case 1:
+		return "visited"
+	case 2:
+		return "landed"
+	}
+
+	return fmt.Sprintf("exploration %d", v)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
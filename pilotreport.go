@@ -0,0 +1,353 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/imle/resourcefork"
+)
+
+// A pilot report summarises a pilot's progress for sharing. NpiL leaves the pilot's mission slots (MissionObjective
+// and MissionData) undecoded, so the active and completed missions are only a heuristic based on the control bits: a
+// mïsn whose OnSuccess sets bits that are all set counts as completed, and one whose OnAccept sets bits that are all
+// set, but that isn't completed, counts as active. Legal status is kept per system, so each govt's standing is the
+// average over the systems it owns, and it's hostile if it's hostile in any of them. Net worth is the pilot's cash,
+// plus the Cost of their ship and every outfit they carry.
+
+type PilotReport struct {
+	Name     string `json:"name"`
+	Nickname string `json:"nickname,omitempty"`
+	Date     string `json:"date"`
+
+	Ship         PilotReportItem `json:"ship"`
+	Cash         Credits         `json:"cash"`
+	OutfitValue  Credits         `json:"outfitValue"`
+	NetWorth     Credits         `json:"netWorth"`
+	CombatRating int16           `json:"combatRating"`
+	RatingLabel  string          `json:"ratingLabel,omitempty"`
+
+	Exploration PilotReportExploration `json:"exploration"`
+	Dominated   []PilotReportItem      `json:"dominated"`
+	Legal       []PilotReportLegal     `json:"legal"`
+	Missions    PilotReportMissions    `json:"missions"`
+	Outfits     []PilotReportItem      `json:"outfits"`
+	Ranks       []PilotReportItem      `json:"ranks"`
+}
+
+// PilotReportItem names a resource, with a count and value where they apply.
+type PilotReportItem struct {
+	ID    IDType  `json:"id"`
+	Name  string  `json:"name"`
+	Count int     `json:"count,omitempty"`
+	Value Credits `json:"value,omitempty"`
+}
+
+type PilotReportExploration struct {
+	Visible  int     `json:"visible"`
+	Explored int     `json:"explored"`
+	Percent  float64 `json:"percent"`
+}
+
+type PilotReportLegal struct {
+	Govt    GovtID `json:"govt"`
+	Name    string `json:"name"`
+	Status  int16  `json:"status"`
+	Label   string `json:"label"`
+	Hostile bool   `json:"hostile"`
+}
+
+type PilotReportMissions struct {
+	Active    []PilotReportItem `json:"active"`
+	Completed []PilotReportItem `json:"completed"`
+}
+
+// NewPilotReport builds the report for the pilot.
+func NewPilotReport(p *NpiL, lib *ResourceLibrary) (*PilotReport, error) {
+	r := &PilotReport{
+		Name:         p.Name,
+		Nickname:     p.Nickname(),
+		Date:         p.Date(),
+		Cash:         p.Cash,
+		CombatRating: p.CombatRating,
+		RatingLabel:  p.CombatRatingLabel(lib),
+		Dominated:    []PilotReportItem{},
+		Legal:        []PilotReportLegal{},
+		Missions:     PilotReportMissions{Active: []PilotReportItem{}, Completed: []PilotReportItem{}},
+		Outfits:      []PilotReportItem{},
+		Ranks:        []PilotReportItem{},
+	}
+
+	ship, ok := lib.Ships[p.ShipClass]
+	if !ok {
+		return nil, fmt.Errorf("unknown shïp %d", p.ShipClass)
+	}
+	r.Ship = PilotReportItem{ID: IDType(ship.ID), Name: shipName(lib, ship), Value: ship.Cost}
+
+	for _, id := range sortedSystIDs(lib.Systs) {
+		visible, err := lib.Systs[id].Visibility.Eval(p)
+		if err != nil {
+			return nil, fmt.Errorf("sÿst %d: %v", id, err)
+		}
+		if !visible {
+			continue
+		}
+
+		r.Exploration.Visible++
+		if p.Explored(id) {
+			r.Exploration.Explored++
+		}
+	}
+	if r.Exploration.Visible > 0 {
+		r.Exploration.Percent = math.Round(float64(r.Exploration.Explored)/float64(r.Exploration.Visible)*1000) / 10
+	}
+
+	for i, v := range p.StellarDominated {
+		if v == 0 {
+			continue
+		}
+
+		id := IDType(i + resourcefork.ResourceForkIDOffset)
+		if _, ok := lib.Spobs[SpobID(id)]; ok {
+			r.Dominated = append(r.Dominated, PilotReportItem{ID: id, Name: lib.ResourceName("spöb", id)})
+		}
+	}
+
+	r.Legal = pilotLegal(p, lib)
+
+	misns, err := pilotMissions(p, lib)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range misns {
+		item := PilotReportItem{ID: IDType(m.misn.ID), Name: lib.ResourceName("mïsn", IDType(m.misn.ID))}
+		if m.completed {
+			r.Missions.Completed = append(r.Missions.Completed, item)
+		} else if m.active {
+			r.Missions.Active = append(r.Missions.Active, item)
+		}
+	}
+
+	for _, id := range sortedOutfIDs(lib.Outfs) {
+		n := p.OutfitCount(id)
+		if n <= 0 {
+			continue
+		}
+
+		o := lib.Outfs[id]
+		value := o.Cost * Credits(n)
+		if o.Flags.PriceProportionalToShipMass {
+			value *= Credits(ship.Mass)
+		}
+		r.Outfits = append(r.Outfits, PilotReportItem{ID: IDType(id), Name: outfName(lib, o), Count: int(n), Value: value})
+		r.OutfitValue += value
+	}
+	r.NetWorth = r.Cash + ship.Cost + r.OutfitValue
+
+	for _, rank := range NewRankModel(lib).Active(p) {
+		r.Ranks = append(r.Ranks, PilotReportItem{ID: IDType(rank.ID), Name: rank.ConvName})
+	}
+
+	return r, nil
+}
+
+func (r *PilotReport) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func (r *PilotReport) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "%s\n", r.Name)
+	if r.Nickname != "" {
+		fmt.Fprintf(b, "Flying the %s %q\n", r.Ship.Name, r.Nickname)
+	} else {
+		fmt.Fprintf(b, "Flying a %s\n", r.Ship.Name)
+	}
+	fmt.Fprintf(b, "Date: %s\n", r.Date)
+	fmt.Fprintf(b, "Cash: %d cr   Outfits: %d cr   Net worth: %d cr\n", r.Cash, r.OutfitValue, r.NetWorth)
+	if r.RatingLabel != "" {
+		fmt.Fprintf(b, "Combat rating: %s (%d)\n", r.RatingLabel, r.CombatRating)
+	} else {
+		fmt.Fprintf(b, "Combat rating: %d\n", r.CombatRating)
+	}
+	fmt.Fprintf(b, "Explored: %d of %d systems (%.1f%%)\n", r.Exploration.Explored, r.Exploration.Visible, r.Exploration.Percent)
+	fmt.Fprintf(b, "Missions: %d active, %d completed\n", len(r.Missions.Active), len(r.Missions.Completed))
+
+	section := func(title, typ string, items []PilotReportItem) {
+		if len(items) == 0 {
+			return
+		}
+
+		fmt.Fprintf(b, "\n%s:\n", title)
+		for _, it := range items {
+			fmt.Fprintf(b, "  %s", reportName(typ, it))
+			if it.Count > 0 {
+				fmt.Fprintf(b, " x%d", it.Count)
+			}
+			if it.Value > 0 {
+				fmt.Fprintf(b, " (%d cr)", it.Value)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	section("Ranks", "ränk", r.Ranks)
+	section("Outfits", "oütf", r.Outfits)
+	section("Active missions", "mïsn", r.Missions.Active)
+	section("Completed missions", "mïsn", r.Missions.Completed)
+	section("Dominated", "spöb", r.Dominated)
+
+	if len(r.Legal) > 0 {
+		b.WriteString("\nLegal status:\n")
+		for _, l := range r.Legal {
+			name := l.Name
+			if name == "" {
+				name = fmt.Sprintf("gövt %d", l.Govt)
+			}
+			fmt.Fprintf(b, "  %s: %s (%d)", name, l.Label, l.Status)
+			if l.Hostile {
+				b.WriteString(", hostile")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func reportName(typ string, it PilotReportItem) string {
+	if it.Name != "" {
+		return it.Name
+	}
+
+	return fmt.Sprintf("%s %d", typ, it.ID)
+}
+
+func shipName(lib *ResourceLibrary, s *Ship) string {
+	if s.ShortName != "" {
+		return s.ShortName
+	}
+	if n := lib.ResourceName("shïp", IDType(s.ID)); n != "" {
+		return n
+	}
+
+	return fmt.Sprintf("shïp %d", s.ID)
+}
+
+func outfName(lib *ResourceLibrary, o *Outf) string {
+	if n := lib.ResourceName("oütf", IDType(o.ID)); n != "" {
+		return n
+	}
+	if o.ShortName != "" {
+		return o.ShortName
+	}
+
+	return fmt.Sprintf("oütf %d", o.ID)
+}
+
+// pilotLegal returns the pilot's standing with every govt that owns systems, in govt ID order.
+func pilotLegal(p *NpiL, lib *ResourceLibrary) []PilotReportLegal {
+	engine := NewLegalEngine(lib)
+
+	type sum struct {
+		status, systs int
+		hostile       bool
+	}
+	sums := map[GovtID]*sum{}
+	for _, s := range engine.Standings(&p.LegalStatus) {
+		if s.Govt == GovtIDIndependent {
+			continue
+		}
+		v, ok := sums[s.Govt]
+		if !ok {
+			v = &sum{}
+			sums[s.Govt] = v
+		}
+		v.status += int(s.Status)
+		v.systs++
+		v.hostile = v.hostile || s.Hostile
+	}
+
+	ids := make([]GovtID, 0, len(sums))
+	for id := range sums {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	out := []PilotReportLegal{}
+	for _, id := range ids {
+		v := sums[id]
+		status := int16(math.Round(float64(v.status) / float64(v.systs)))
+		out = append(out, PilotReportLegal{
+			Govt:    id,
+			Name:    lib.ResourceName("gövt", IDType(id)),
+			Status:  status,
+			Label:   engine.Label(status),
+			Hostile: v.hostile,
+		})
+	}
+
+	return out
+}
+
+type pilotMission struct {
+	misn      *Misn
+	active    bool
+	completed bool
+}
+
+// pilotMissions infers the state of every mïsn from the bits its OnAccept and OnSuccess set, in ID order.
+func pilotMissions(p *NpiL, lib *ResourceLibrary) ([]pilotMission, error) {
+	ids := sortedMisnIDs(lib.Misns)
+
+	out := make([]pilotMission, 0, len(ids))
+	for _, id := range ids {
+		m := lib.Misns[id]
+
+		completed, err := allBitsSet(p, m.OnSuccess)
+		if err != nil {
+			return nil, fmt.Errorf("mïsn %d OnSuccess: %v", id, err)
+		}
+		accepted, err := allBitsSet(p, m.OnAccept)
+		if err != nil {
+			return nil, fmt.Errorf("mïsn %d OnAccept: %v", id, err)
+		}
+
+		out = append(out, pilotMission{misn: m, active: accepted && !completed, completed: completed})
+	}
+
+	return out, nil
+}
+
+// allBitsSet reports whether the set string sets at least one bit and the pilot has every one of them set. Random
+// choices are skipped.
+func allBitsSet(p *NpiL, f ControlBitFunction) (bool, error) {
+	ops, err := f.Parse()
+	if err != nil {
+		return false, err
+	}
+
+	n := 0
+	for _, op := range ops {
+		if op.Op != 'b' {
+			continue
+		}
+		if !p.Bit(op.Arg) {
+			return false, nil
+		}
+		n++
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,182 @@
+package resources
+
+import (
+	"fmt"
+)
+
+// The rank model works out what the player's active ranks give them and take away:
+//
+//  Salary      Each active rank pays its Salary every day, but never takes the player's cash above its SalaryCap. A
+//              cap of zero or less means no cap. Ranks are paid in ID order.
+//  PriceMod    At spöbs of a rank's affiliated govt, prices are changed by its PriceMod percent. Several ranks of the
+//              same govt multiply together.
+//  Contribute  The contribute bits of every active rank.
+//
+// Ranks are deactivated by the flags on them or on the rank being activated or deactivated. Permanent ranks are only
+// ever deactivated explicitly, and ranks deactivated as a side effect don't in turn deactivate others.
+
+type RankEventKind int8
+
+const (
+	RankEventCrime       RankEventKind = iota // The player commits a crime against Govt.
+	RankEventDisableShip                      // The player disables or destroys a ship of Govt.
+	RankEventActivate                         // Rank is activated.
+	RankEventDeactivate                       // Rank is explicitly deactivated.
+)
+
+func (k RankEventKind) String() string {
+	switch k {
+	case RankEventCrime:
+		return "Crime"
+	case RankEventDisableShip:
+		return "DisableShip"
+	case RankEventActivate:
+		return "Activate"
+	case RankEventDeactivate:
+		return "Deactivate"
+	default:
+		return fmt.Sprintf("RankEventKind(%d)", k)
+	}
+}
+
+type RankEvent struct {
+	Kind RankEventKind
+	Govt GovtID // For crimes and disabled ships.
+	Rank RankID // For activations and deactivations.
+}
+
+type RankModel struct {
+	Lib       *ResourceLibrary
+	Relations *GovtRelations
+}
+
+func NewRankModel(lib *ResourceLibrary) *RankModel {
+	return &RankModel{
+		Lib:       lib,
+		Relations: NewGovtRelations(lib.Govts),
+	}
+}
+
+// Active returns the pilot's active ranks in ID order.
+func (m *RankModel) Active(p *NpiL) []*Rank {
+	var out []*Rank
+	for _, id := range sortedRankIDs(m.Lib.Ranks) {
+		if p.RankIsActive(id) {
+			out = append(out, m.Lib.Ranks[id])
+		}
+	}
+
+	return out
+}
+
+// Salary returns the credits the pilot's ranks pay them for one day, starting from their current cash.
+func (m *RankModel) Salary(p *NpiL) Credits {
+	cash := p.Cash
+	for _, r := range m.Active(p) {
+		pay := Credits(r.Salary)
+		if r.SalaryCap > 0 {
+			if room := Credits(r.SalaryCap) - cash; room < pay {
+				pay = room
+			}
+		}
+		if pay > 0 {
+			cash += pay
+		}
+	}
+
+	return cash - p.Cash
+}
+
+// PriceMod returns the factor the pilot's ranks multiply prices by at spöbs of the govt, 1 for no change.
+func (m *RankModel) PriceMod(p *NpiL, govt GovtID) float64 {
+	mod := 1.0
+	for _, r := range m.Active(p) {
+		if r.AffilGovt == govt && r.PriceMod != 0 {
+			mod *= 1 + float64(r.PriceMod)/100
+		}
+	}
+	if mod < 0 {
+		mod = 0
+	}
+
+	return mod
+}
+
+// Contribute combines the contribute bits of the pilot's active ranks.
+func (m *RankModel) Contribute(p *NpiL) FlagMask64 {
+	var c FlagMask64
+	for _, r := range m.Active(p) {
+		c |= r.Contribute
+	}
+
+	return c
+}
+
+// Deactivated returns the IDs of the active ranks the event deactivates, in ID order, without changing the pilot.
+func (m *RankModel) Deactivated(p *NpiL, e RankEvent) ([]RankID, error) {
+	var trigger *Rank
+	if e.Kind == RankEventActivate || e.Kind == RankEventDeactivate {
+		r, ok := m.Lib.Ranks[e.Rank]
+		if !ok {
+			return nil, fmt.Errorf("unknown rank %d", e.Rank)
+		}
+		trigger = r
+	}
+
+	var out []RankID
+	for _, r := range m.Active(p) {
+		if trigger != nil && r.ID == trigger.ID {
+			if e.Kind == RankEventDeactivate {
+				out = append(out, r.ID)
+			}
+			continue
+		}
+		if r.Flags.Permanent {
+			continue
+		}
+
+		switch e.Kind {
+		case RankEventCrime:
+			if r.Flags.DeactivateOnCrimeAgainstGovt && r.AffilGovt == e.Govt {
+				out = append(out, r.ID)
+			}
+
+		case RankEventDisableShip:
+			if r.Flags.DeactivateOnGovtShipDisableOrDestroy && m.Relations.IsAlly(r.AffilGovt, e.Govt) {
+				out = append(out, r.ID)
+			}
+
+		case RankEventActivate, RankEventDeactivate:
+			if r.AffilGovt != trigger.AffilGovt {
+				continue
+			}
+
+			all, lower := trigger.Flags.DeactivateAllOtherForGovtOnActivate, trigger.Flags.DeactivateAllLowerForGovtOnActivate
+			if e.Kind == RankEventDeactivate {
+				all, lower = trigger.Flags.DeactivateAllOtherForGovtOnDeactivate, trigger.Flags.DeactivateAllLowerForGovtOnDeactivate
+			}
+			if all || (lower && r.Weight < trigger.Weight) {
+				out = append(out, r.ID)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// Apply changes the pilot's ranks for the event and returns the ranks it deactivated.
+func (m *RankModel) Apply(p *NpiL, e RankEvent) ([]RankID, error) {
+	out, err := m.Deactivated(p, e)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range out {
+		p.SetRankActive(id, false)
+	}
+	if e.Kind == RankEventActivate {
+		p.SetRankActive(e.Rank, true)
+	}
+
+	return out, nil
+}
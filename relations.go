@@ -0,0 +1,164 @@
+package resources
+
+import (
+	"sort"
+)
+
+// Governments don't name each other directly. Each gövt resource lists up to four classes it belongs to, and up to
+// four classes it considers allies and enemies. A govt is allied with (or an enemy of) every govt that belongs to one
+// of its Ally (or Enemy) classes. GovtRelations resolves those class lists into a full govt-by-govt matrix.
+
+type GovtRelation int8
+
+const (
+	GovtRelationNeutral GovtRelation = iota // Neither allied nor hostile.
+	GovtRelationAlly                        // Allied, or the same govt.
+	GovtRelationEnemy                       // Hostile.
+)
+
+func (r GovtRelation) String() string {
+	switch r {
+	case GovtRelationAlly:
+		return "Ally"
+	case GovtRelationEnemy:
+		return "Enemy"
+	default:
+		return "Neutral"
+	}
+}
+
+// GovtIDIndependent is used throughout the data files for ships and systems that don't belong to any government.
+const GovtIDIndependent GovtID = -1
+
+type GovtRelations struct {
+	govts  []GovtID
+	index  map[GovtID]int
+	matrix [][]GovtRelation
+}
+
+func NewGovtRelations(govts map[GovtID]*Govt) *GovtRelations {
+	r := &GovtRelations{
+		govts: make([]GovtID, 0, len(govts)),
+		index: map[GovtID]int{},
+	}
+
+	for id := range govts {
+		r.govts = append(r.govts, id)
+	}
+	sort.Slice(r.govts, func(i, j int) bool { return r.govts[i] < r.govts[j] })
+
+	for i, id := range r.govts {
+		r.index[id] = i
+	}
+
+	// Reverse lookup of class -> member govts
+	members := map[int16][]GovtID{}
+	for _, id := range r.govts {
+		for _, class := range govts[id].Class {
+			if class < 0 {
+				continue
+			}
+			members[class] = append(members[class], id)
+		}
+	}
+
+	r.matrix = make([][]GovtRelation, len(r.govts))
+	for i, id := range r.govts {
+		r.matrix[i] = make([]GovtRelation, len(r.govts))
+		r.matrix[i][i] = GovtRelationAlly
+
+		g := govts[id]
+		for _, class := range g.Ally {
+			if class < 0 {
+				continue
+			}
+			for _, other := range members[class] {
+				if other != id {
+					r.matrix[i][r.index[other]] = GovtRelationAlly
+				}
+			}
+		}
+
+		// Enemy classes are applied last, so a govt listed in both an ally and an enemy class is hostile.
+		for _, class := range g.Enemy {
+			if class < 0 {
+				continue
+			}
+			for _, other := range members[class] {
+				if other != id {
+					r.matrix[i][r.index[other]] = GovtRelationEnemy
+				}
+			}
+		}
+	}
+
+	return r
+}
+
+// Govts returns every known govt ID in ascending order.
+func (r *GovtRelations) Govts() []GovtID {
+	return append([]GovtID(nil), r.govts...)
+}
+
+// Relation reports how the govt from regards the govt to. Relations are not necessarily symmetric. Independent or
+// unknown govts are always neutral, except to themselves.
+func (r *GovtRelations) Relation(from, to GovtID) GovtRelation {
+	if from == to {
+		return GovtRelationAlly
+	}
+
+	i, ok := r.index[from]
+	if !ok {
+		return GovtRelationNeutral
+	}
+	j, ok := r.index[to]
+	if !ok {
+		return GovtRelationNeutral
+	}
+
+	return r.matrix[i][j]
+}
+
+func (r *GovtRelations) IsAlly(from, to GovtID) bool {
+	return r.Relation(from, to) == GovtRelationAlly
+}
+
+func (r *GovtRelations) IsEnemy(from, to GovtID) bool {
+	return r.Relation(from, to) == GovtRelationEnemy
+}
+
+// Allies returns the govts that the given govt considers allies, not including itself.
+func (r *GovtRelations) Allies(id GovtID) []GovtID {
+	return r.filter(id, GovtRelationAlly)
+}
+
+// Enemies returns the govts that the given govt considers enemies.
+func (r *GovtRelations) Enemies(id GovtID) []GovtID {
+	return r.filter(id, GovtRelationEnemy)
+}
+
+func (r *GovtRelations) filter(id GovtID, rel GovtRelation) []GovtID {
+	i, ok := r.index[id]
+	if !ok {
+		return nil
+	}
+
+	var out []GovtID
+	for j, other := range r.govts {
+		if other != id && r.matrix[i][j] == rel {
+			out = append(out, other)
+		}
+	}
+
+	return out
+}
+
+// Matrix returns a copy of the full relation matrix, with rows and columns ordered as in Govts.
+func (r *GovtRelations) Matrix() [][]GovtRelation {
+	out := make([][]GovtRelation, len(r.matrix))
+	for i := range r.matrix {
+		out[i] = append([]GovtRelation(nil), r.matrix[i]...)
+	}
+
+	return out
+}
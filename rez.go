@@ -0,0 +1,322 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/imle/resourcefork"
+)
+
+// The Windows port of Nova stores its data files and plugins as .rez archives rather than Mac resource forks. The
+// header and index are little-endian, the resource map is big-endian:
+//
+//  Header        "BRGR", group count (1), header length (bytes following these first 12).
+//  Group         Group type (1), index of the first entry, entry count (resources + 1 for the map).
+//  Index         Per entry: data offset, data length, reserved (0). The resource map is the last entry.
+//  Map name      "resource.map", null terminated.
+//  Data          The resource data, back to back.
+//  Map           Reserved (8), type count, then per type: code, offset of its resource list within the map,
+//                resource count. Each resource list entry is the resource's entry index, its type code, its ID and
+//                a 256 byte null-terminated name.
+
+var ErrRezFormat = errors.New("malformed rez archive")
+
+const (
+	rezMagic         = "BRGR"
+	rezMapName       = "resource.map"
+	rezFirstIndex    = 1
+	rezMapReserved   = 8
+	rezTypeInfoLen   = 12
+	rezResInfoLen    = 266
+	rezResNameLen    = 256
+	rezIndexEntryLen = 12
+)
+
+// IsRez reports whether the bytes start with the .rez signature.
+func IsRez(b []byte) bool {
+	return len(b) >= 4 && string(b[:4]) == rezMagic
+}
+
+func rezErrorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrRezFormat, fmt.Sprintf(format, args...))
+}
+
+func ReadRezFromBytes(b []byte) (*resourcefork.ResourceFork, error) {
+	if !IsRez(b) {
+		return nil, rezErrorf("missing %q signature", rezMagic)
+	}
+	if len(b) < 24 {
+		return nil, rezErrorf("truncated header")
+	}
+
+	le := binary.LittleEndian
+	if groups := le.Uint32(b[4:]); groups != 1 {
+		return nil, rezErrorf("unsupported group count %d", groups)
+	}
+
+	first := le.Uint32(b[16:])
+	count := le.Uint32(b[20:])
+	if count == 0 || uint64(24)+uint64(count)*rezIndexEntryLen > uint64(len(b)) {
+		return nil, rezErrorf("index of %d entries doesn't fit", count)
+	}
+
+	entry := func(index uint32) ([]byte, error) {
+		i := uint64(index) - uint64(first)
+		if index < first || i >= uint64(count) {
+			return nil, rezErrorf("entry %d out of range", index)
+		}
+
+		at := 24 + i*rezIndexEntryLen
+		offset, length := uint64(le.Uint32(b[at:])), uint64(le.Uint32(b[at+4:]))
+		if offset+length > uint64(len(b)) {
+			return nil, rezErrorf("entry %d runs past the end of the file", index)
+		}
+
+		return b[offset : offset+length : offset+length], nil
+	}
+
+	m, err := entry(first + count - 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(m) < 8 {
+		return nil, rezErrorf("truncated resource map")
+	}
+
+	be := binary.BigEndian
+	types := be.Uint32(m[4:])
+	if uint64(8)+uint64(types)*rezTypeInfoLen > uint64(len(m)) {
+		return nil, rezErrorf("type list of %d types doesn't fit", types)
+	}
+
+	rf := &resourcefork.ResourceFork{Resources: map[string]map[uint16]resourcefork.Resource{}}
+	for t := uint32(0); t < types; t++ {
+		info := m[8+t*rezTypeInfoLen:]
+		typ := decodeMacRoman(info[0:4])
+		list, n := uint64(be.Uint32(info[4:])), uint64(be.Uint32(info[8:]))
+		if list+n*rezResInfoLen > uint64(len(m)) {
+			return nil, rezErrorf("resource list of %q doesn't fit", typ)
+		}
+
+		if _, ok := rf.Resources[typ]; ok {
+			return nil, rezErrorf("duplicate resource type %q", typ)
+		}
+		rf.Resources[typ] = map[uint16]resourcefork.Resource{}
+
+		for r := uint64(0); r < n; r++ {
+			res := m[list+r*rezResInfoLen:]
+
+			data, err := entry(be.Uint32(res[0:]))
+			if err != nil {
+				return nil, err
+			}
+
+			name := res[10 : 10+rezResNameLen]
+			if i := bytes.IndexByte(name, 0); i >= 0 {
+				name = name[:i]
+			}
+
+			id := be.Uint16(res[8:])
+			rf.Resources[typ][id] = resourcefork.Resource{
+				Type: typ,
+				ID:   id,
+				Name: decodeMacRoman(name),
+				Data: append([]byte{}, data...),
+			}
+		}
+	}
+
+	return rf, nil
+}
+
+// WriteRez writes the resources as a .rez archive. Types and IDs are written in sorted order so the output is stable.
+func WriteRez(w io.Writer, rf *resourcefork.ResourceFork) error {
+	typeNames := make([]string, 0, len(rf.Resources))
+	for typ, resources := range rf.Resources {
+		if len(resources) > 0 {
+			typeNames = append(typeNames, typ)
+		}
+	}
+	sort.Strings(typeNames)
+
+	var resources []resourcefork.Resource
+	for _, typ := range typeNames {
+		code := encodeMacRoman(typ)
+		if len(code) != 4 {
+			return rezErrorf("resource type %q isn't four characters", typ)
+		}
+
+		ids := make([]int, 0, len(rf.Resources[typ]))
+		for id := range rf.Resources[typ] {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			res := rf.Resources[typ][uint16(id)]
+			res.Type = typ
+			res.ID = uint16(id)
+			resources = append(resources, res)
+		}
+	}
+
+	// Resource map
+	var m bytes.Buffer
+	be := binary.BigEndian
+	_ = binary.Write(&m, be, uint32(rezMapReserved))
+	_ = binary.Write(&m, be, uint32(len(typeNames)))
+
+	list := 8 + len(typeNames)*rezTypeInfoLen
+	for _, typ := range typeNames {
+		n := len(rf.Resources[typ])
+		m.Write(encodeMacRoman(typ))
+		_ = binary.Write(&m, be, uint32(list))
+		_ = binary.Write(&m, be, uint32(n))
+		list += n * rezResInfoLen
+	}
+
+	for i, res := range resources {
+		var name [rezResNameLen]byte
+		copy(name[:rezResNameLen-1], encodeMacRoman(res.Name))
+
+		_ = binary.Write(&m, be, uint32(rezFirstIndex+i))
+		m.Write(encodeMacRoman(res.Type))
+		_ = binary.Write(&m, be, res.ID)
+		m.Write(name[:])
+	}
+
+	// Header and index
+	var out bytes.Buffer
+	le := binary.LittleEndian
+	count := len(resources) + 1
+	headerLen := 12 + count*rezIndexEntryLen + len(rezMapName) + 1
+
+	out.WriteString(rezMagic)
+	_ = binary.Write(&out, le, uint32(1))
+	_ = binary.Write(&out, le, uint32(headerLen))
+	_ = binary.Write(&out, le, uint32(1))
+	_ = binary.Write(&out, le, uint32(rezFirstIndex))
+	_ = binary.Write(&out, le, uint32(count))
+
+	offset := 12 + headerLen
+	for _, res := range resources {
+		_ = binary.Write(&out, le, uint32(offset))
+		_ = binary.Write(&out, le, uint32(len(res.Data)))
+		_ = binary.Write(&out, le, uint32(0))
+		offset += len(res.Data)
+	}
+	_ = binary.Write(&out, le, uint32(offset))
+	_ = binary.Write(&out, le, uint32(m.Len()))
+	_ = binary.Write(&out, le, uint32(0))
+
+	out.WriteString(rezMapName)
+	out.WriteByte(0)
+
+	for _, res := range resources {
+		out.Write(res.Data)
+	}
+	out.Write(m.Bytes())
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// ReadResourcesFromBytes reads either a .rez archive or a Mac resource fork, telling them apart by their magic bytes.
+func ReadResourcesFromBytes(b []byte) (*resourcefork.ResourceFork, error) {
+	if IsRez(b) {
+		return ReadRezFromBytes(b)
+	}
+
+	return resourcefork.ReadResourceForkFromBytes(b)
+}
+
+func isResourceFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".ndat", ".rez":
+		return true
+	}
+
+	return false
+}
+
+func resourceFilesFromPath(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, v := range infos {
+		if !v.IsDir() && !isResourceFile(v.Name()) {
+			continue
+		}
+
+		paths, err := resourceFilesFromPath(filepath.Join(path, v.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, paths...)
+	}
+
+	return out, nil
+}
+
+// ReadResourcesFromPath reads and merges .ndat and .rez files, searching directories recursively. Files are read in
+// name order, so later files override resources from earlier ones the way plugins do, except for STR# resources,
+// which are patched (see StrA.Patch).
+func ReadResourcesFromPath(paths ...string) (*resourcefork.ResourceFork, error) {
+	var files []string
+	for _, v := range paths {
+		found, err := resourceFilesFromPath(v)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+
+	merged := &resourcefork.ResourceFork{Resources: map[string]map[uint16]resourcefork.Resource{}}
+	for _, v := range files {
+		b, err := ioutil.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+
+		rf, err := ReadResourcesFromBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v, err)
+		}
+
+		for typ, resources := range rf.Resources {
+			if _, ok := merged.Resources[typ]; !ok {
+				merged.Resources[typ] = map[uint16]resourcefork.Resource{}
+			}
+			for id, res := range resources {
+				if base, ok := merged.Resources[typ][id]; ok && typ == "STR#" {
+					res.Data = StrAFromResource(base).Patch(StrAFromResource(res)).Bytes()
+				}
+				merged.Resources[typ][id] = res
+			}
+		}
+	}
+
+	delete(merged.Resources, "csüm") // Don't care about checksum
+	delete(merged.Resources, "dsïg") // Don't care about digital signature
+
+	return merged, nil
+}
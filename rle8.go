@@ -0,0 +1,212 @@
+package resources
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"log"
+
+	"github.com/imle/resourcefork"
+)
+
+type Rle8ID IDType
+
+// Rle8 is the 8-bit counterpart of RleD. Pixels are indices into the standard Mac 256-colour palette.
+type Rle8 struct {
+	ID          Rle8ID
+	Image       image.Image
+	Rectangle   image.Rectangle
+	CountAcross int
+	CountDown   int
+
+	data []byte // The undecoded resource, when the library was loaded lazily.
+}
+
+// MacPalette8 is the standard Mac 256-colour CLUT: a 6x6x6 colour cube from white down to (but not including)
+// black, then ten-step ramps of red, green, blue and grey, then black.
+var MacPalette8 = func() color.Palette {
+	p := make(color.Palette, 0, 256)
+	for i := 0; i < 215; i++ {
+		p = append(p, color.NRGBA{
+			R: uint8(0x33 * (5 - i/36)),
+			G: uint8(0x33 * (5 - i/6%6)),
+			B: uint8(0x33 * (5 - i%6)),
+			A: 0xFF,
+		})
+	}
+
+	ramp := []uint8{0xEE, 0xDD, 0xBB, 0xAA, 0x88, 0x77, 0x55, 0x44, 0x22, 0x11}
+	for _, v := range ramp {
+		p = append(p, color.NRGBA{R: v, A: 0xFF})
+	}
+	for _, v := range ramp {
+		p = append(p, color.NRGBA{G: v, A: 0xFF})
+	}
+	for _, v := range ramp {
+		p = append(p, color.NRGBA{B: v, A: 0xFF})
+	}
+	for _, v := range ramp {
+		p = append(p, color.NRGBA{R: v, G: v, B: v, A: 0xFF})
+	}
+
+	return append(p, color.NRGBA{A: 0xFF})
+}()
+
+func Rle8FromResource(resource resourcefork.Resource) *Rle8 {
+	rle, e := Rle8FromBytes(Rle8ID(resource.ID), resource.Data)
+	if e != nil {
+		log.Fatal(e)
+	}
+
+	return rle
+}
+
+func Rle8FromBytes(id Rle8ID, b []byte) (*Rle8, error) {
+	rle, err := decodeRle(b, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Rle8{
+		ID:          id,
+		Image:       rle.Image,
+		Rectangle:   rle.Rectangle,
+		CountAcross: rle.CountAcross,
+		CountDown:   rle.CountDown,
+	}
+
+	return t, nil
+}
+
+const (
+	rleHeaderLen        = 16
+	rleOpEndOfFrame     = 0
+	rleOpLineStart      = 1
+	rleOpPixelData      = 2
+	rleOpTransparentRun = 3
+	rleOpPixelRun       = 4
+)
+
+var ErrRleFormat = errors.New("malformed rlë resource")
+
+type rleSheet struct {
+	Image       *image.NRGBA
+	Rectangle   image.Rectangle
+	CountAcross int
+	CountDown   int
+}
+
+// rleSheetLayout picks how many frames go across the sprite sheet, the same way gomacimage does for rlëD so that
+// both depths lay their frames out identically.
+func rleSheetLayout(frames int) (across, down int) {
+	for _, d := range []int{16, 12, 8, 6, 4, 2} {
+		if frames%d == 0 {
+			return d, frames / d
+		}
+	}
+
+	return 1, frames
+}
+
+// decodeRle decodes an rlë resource of the given depth into a sprite sheet. Every token is a 32-bit word holding
+// the opcode in its top byte and a byte count in the rest; pixel data is padded to a multiple of four bytes.
+func decodeRle(b []byte, depth int) (*rleSheet, error) {
+	if len(b) < rleHeaderLen {
+		return nil, ErrRleFormat
+	}
+
+	be := binary.BigEndian
+	width, height := int(be.Uint16(b[0:])), int(be.Uint16(b[2:]))
+	if int(be.Uint16(b[4:])) != depth {
+		return nil, errors.New("invalid color depth in rlë resource")
+	}
+
+	frames := int(be.Uint16(b[8:]))
+	across, down := rleSheetLayout(frames)
+	bpp := depth / 8
+
+	sheet := &rleSheet{
+		Image:       image.NewNRGBA(image.Rect(0, 0, width*across, height*down)),
+		Rectangle:   image.Rect(0, 0, width, height),
+		CountAcross: across,
+		CountDown:   down,
+	}
+
+	set := func(x, y int, px []byte) {
+		if depth == 8 {
+			sheet.Image.Set(x, y, MacPalette8[px[0]])
+			return
+		}
+		sheet.Image.Set(x, y, rgb555(be.Uint16(px)))
+	}
+
+	pos := rleHeaderLen
+	for frame := 0; frame < frames; frame++ {
+		left, top := frame%across*width, frame/across*height
+		line, x := -1, 0
+
+		for done := false; !done; {
+			if pos+4 > len(b) {
+				return nil, ErrRleFormat
+			}
+			token := be.Uint32(b[pos:])
+			pos += 4
+			count := int(token & 0x00FFFFFF)
+
+			switch token >> 24 {
+			case rleOpEndOfFrame:
+				if line != height-1 {
+					return nil, errors.New("incorrect number of scan lines in rlë resource")
+				}
+				done = true
+
+			case rleOpLineStart:
+				line++
+				x = 0
+
+			case rleOpPixelData:
+				if pos+count > len(b) {
+					return nil, ErrRleFormat
+				}
+				for i := 0; i+bpp <= count; i += bpp {
+					set(left+x, top+line, b[pos+i:])
+					x++
+				}
+				pos += (count + 3) &^ 3
+
+			case rleOpTransparentRun:
+				x += count / bpp
+
+			case rleOpPixelRun:
+				if pos+4 > len(b) {
+					return nil, ErrRleFormat
+				}
+				for i := 0; i < count/bpp; i++ {
+					set(left+x, top+line, b[pos:])
+					x++
+				}
+				pos += 4
+
+			default:
+				return nil, errors.New("invalid opcode encountered in rlë resource")
+			}
+		}
+	}
+
+	return sheet, nil
+}
+
+func rgb555(px uint16) color.NRGBA {
+	expand := func(v uint16) uint8 {
+		v <<= 3
+		return uint8(v | v>>5)
+	}
+
+	return color.NRGBA{
+		R: expand(px >> 10 & 0x1F),
+		G: expand(px >> 5 & 0x1F),
+		B: expand(px & 0x1F),
+		A: 0xFF,
+	}
+}
@@ -20,6 +20,8 @@ type RleD struct {
 	Rectangle   image.Rectangle
 	CountAcross int
 	CountDown   int
+
+	data []byte // The undecoded resource, when the library was loaded lazily.
 }
 
 func RleDFromResource(resource resourcefork.Resource) *RleD {
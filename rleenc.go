@@ -0,0 +1,130 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+)
+
+// Pixels with less than half opacity are written as transparent runs.
+const rleAlphaThreshold = 0x8000
+
+// Frames splits the sprite sheet back into its individual frames, in the order they appear in the resource.
+func (r *RleD) Frames() []image.Image {
+	return sheetFrames(r.Image, r.Rectangle, r.CountAcross, r.CountDown)
+}
+
+// Frames splits the sprite sheet back into its individual frames, in the order they appear in the resource.
+func (r *Rle8) Frames() []image.Image {
+	return sheetFrames(r.Image, r.Rectangle, r.CountAcross, r.CountDown)
+}
+
+func sheetFrames(sheet image.Image, frame image.Rectangle, across, down int) []image.Image {
+	sub, ok := sheet.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil
+	}
+
+	w, h := frame.Dx(), frame.Dy()
+	origin := sheet.Bounds().Min
+
+	out := make([]image.Image, 0, across*down)
+	for i := 0; i < across*down; i++ {
+		at := origin.Add(image.Pt(i%across*w, i/across*h))
+		out = append(out, sub.SubImage(image.Rectangle{Min: at, Max: at.Add(image.Pt(w, h))}))
+	}
+
+	return out
+}
+
+// EncodeRleD encodes the frames as a 16-bit rlëD resource. Every frame must be the same size.
+func EncodeRleD(frames []image.Image) ([]byte, error) {
+	return encodeRle(frames, 16, func(c color.Color) []byte {
+		r, g, b, _ := c.RGBA()
+		px := uint16(r>>11)<<10 | uint16(g>>11)<<5 | uint16(b>>11)
+		return []byte{byte(px >> 8), byte(px)}
+	})
+}
+
+// EncodeRle8 encodes the frames as an 8-bit rlë8 resource, mapping each pixel to the nearest colour in MacPalette8.
+// Every frame must be the same size.
+func EncodeRle8(frames []image.Image) ([]byte, error) {
+	return encodeRle(frames, 8, func(c color.Color) []byte {
+		return []byte{byte(MacPalette8.Index(c))}
+	})
+}
+
+func encodeRle(frames []image.Image, depth int, pixel func(color.Color) []byte) ([]byte, error) {
+	if len(frames) == 0 || len(frames) > 0xFFFF {
+		return nil, errors.New("an rlë resource needs between 1 and 65535 frames")
+	}
+
+	size := frames[0].Bounds().Size()
+	for _, f := range frames {
+		if f.Bounds().Size() != size {
+			return nil, errors.New("every frame of an rlë resource must be the same size")
+		}
+	}
+
+	var out bytes.Buffer
+	be := binary.BigEndian
+
+	_ = binary.Write(&out, be, uint16(size.X))
+	_ = binary.Write(&out, be, uint16(size.Y))
+	_ = binary.Write(&out, be, uint16(depth))
+	_ = binary.Write(&out, be, uint16(0)) // Palette, 0 for the default
+	_ = binary.Write(&out, be, uint16(len(frames)))
+	out.Write(make([]byte, 6))
+
+	token := func(b *bytes.Buffer, op int, count int) {
+		_ = binary.Write(b, be, uint32(op)<<24|uint32(count)&0x00FFFFFF)
+	}
+
+	bpp := depth / 8
+	for _, f := range frames {
+		min := f.Bounds().Min
+
+		for y := 0; y < size.Y; y++ {
+			var line bytes.Buffer
+			var run []byte
+			transparent := 0
+
+			flush := func() {
+				if len(run) > 0 {
+					token(&line, rleOpPixelData, len(run))
+					line.Write(run)
+					line.Write(make([]byte, (4-len(run)%4)%4))
+					run = run[:0]
+				}
+			}
+
+			for x := 0; x < size.X; x++ {
+				c := f.At(min.X+x, min.Y+y)
+				if _, _, _, a := c.RGBA(); a < rleAlphaThreshold {
+					flush()
+					transparent++
+					continue
+				}
+
+				if transparent > 0 {
+					token(&line, rleOpTransparentRun, transparent*bpp)
+					transparent = 0
+				}
+				run = append(run, pixel(c)...)
+			}
+			flush()
+
+			// Trailing transparent pixels don't need a run; the line simply ends.
+			token(&out, rleOpLineStart, line.Len())
+			out.Write(line.Bytes())
+		}
+
+		token(&out, rleOpEndOfFrame, 0)
+	}
+
+	return out.Bytes(), nil
+}
@@ -12,18 +12,20 @@ import (
 
 type RoidID IDType
 
+const RoidIDFirst RoidID = 128
+
 type Roid struct {
 	ID RoidID
 
-	Strength    int16
+	Strength    int16 // Damage it takes to destroy the asteroid.
 	SpinRate    int16
-	YieldType   int16
-	YieldQty    int16
+	YieldType   int16 // Commodity type 0-5, or 1000 and up for jünk 128 and up.
+	YieldQty    int16 // The most tons of the commodity a destroyed asteroid releases.
 	PartCount   int16
 	PartColor   color.Color
-	FragType1   RoidID
-	FragType2   RoidID
-	FragCount   int16
+	FragType1   RoidID // Type of the fragments, 0-15 for röid 128-143. Negative if unused.
+	FragType2   RoidID // Type of the fragments, 0-15 for röid 128-143. Negative if unused.
+	FragCount   int16  // Number of fragments the asteroid breaks into.
 	ExplodeType ExplodeType
 	Mass        int16
 }
@@ -55,3 +57,37 @@ func RoidFromBytes(id RoidID, b []byte) *Roid {
 
 	return t
 }
+
+// Commodity returns the standard commodity the asteroid yields, if any.
+func (r *Roid) Commodity() (CommodityType, bool) {
+	if r.YieldType < int16(CommodityTypeFood) || r.YieldType > int16(CommodityTypeEquipment) {
+		return 0, false
+	}
+
+	return CommodityType(r.YieldType), true
+}
+
+// Junk returns the jünk the asteroid yields, if any.
+func (r *Roid) Junk() (JunkID, bool) {
+	if r.YieldType < 1000 {
+		return 0, false
+	}
+
+	return JunkID(r.YieldType - 1000 + 128), true
+}
+
+// Fragments returns the röid IDs the asteroid breaks into. Either type may be used, so a type set in both fields
+// appears twice.
+func (r *Roid) Fragments() []RoidID {
+	var ids []RoidID
+	for _, t := range []RoidID{r.FragType1, r.FragType2} {
+		switch {
+		case t >= 0 && t < 16:
+			ids = append(ids, RoidIDFirst+t)
+		case t >= RoidIDFirst && t < RoidIDFirst+16:
+			ids = append(ids, t)
+		}
+	}
+
+	return ids
+}
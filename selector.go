@@ -0,0 +1,344 @@
+package resources
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Many fields that point at a stellar or a system use an encoded range instead of a plain ID, so that a mission or
+// fleet can refer to "any stellar of this govt" or "the system the player is in". Both selector types share the
+// same encoding:
+//
+//  -1          Stellars: a random inhabited stellar. Systems: any system.
+//  -2          Stellars: a random uninhabited stellar.
+//  -3          The mission's AvailStel (or its system).
+//  -4          Systems: follow the player.
+//  128-2175    A specific stellar or system.
+//  5000-7047   Any stellar or system adjacent to system (value - 5000 + 128).
+//  9999        Any independent stellar or system.
+//  10000-10255 Any stellar or system of govt (value - 10000 + 128).
+//  15000-15255 Any stellar or system of an ally of govt, but not of govt itself (value - 15000 + 128).
+//  20000-20255 Any stellar or system not of govt (value - 20000 + 128).
+//  25000-25255 Any stellar or system of an enemy of govt (value - 25000 + 128).
+//
+// Values outside these ranges are preserved as-is so they can be written back out unchanged.
+
+const (
+	selectorAdjacentBase    = 5000
+	selectorIndependent     = 9999
+	selectorGovtBase        = 10000
+	selectorAllyBase        = 15000
+	selectorNotGovtBase     = 20000
+	selectorEnemyBase       = 25000
+	selectorGovtRange       = 256
+	selectorAdjacentRange   = 2048
+	selectorFirstResourceID = 128
+	selectorLastResourceID  = 2175
+)
+
+type SelectorKind int8
+
+const (
+	SelectorNone              SelectorKind = iota // Unused or unrecognised value.
+	SelectorAny                                   // Stellars: random inhabited. Systems: any.
+	SelectorRandomUninhabited                     // Stellars only.
+	SelectorAvailStel                             // The mission's AvailStel, or its system.
+	SelectorFollowPlayer                          // Systems only.
+	SelectorSpecific                              // A specific stellar or system.
+	SelectorAdjacent                              // Adjacent to a specific system.
+	SelectorIndependent                           // Owned by no govt.
+	SelectorGovt                                  // Owned by the govt.
+	SelectorAlly                                  // Owned by one of the govt's allies, but not the govt itself.
+	SelectorNotGovt                               // Not owned by the govt.
+	SelectorEnemy                                 // Owned by an enemy of the govt.
+)
+
+func (k SelectorKind) String() string {
+	switch k {
+	case SelectorAny:
+		return "Any"
+	case SelectorRandomUninhabited:
+		return "RandomUninhabited"
+	case SelectorAvailStel:
+		return "AvailStel"
+	case SelectorFollowPlayer:
+		return "FollowPlayer"
+	case SelectorSpecific:
+		return "Specific"
+	case SelectorAdjacent:
+		return "Adjacent"
+	case SelectorIndependent:
+		return "Independent"
+	case SelectorGovt:
+		return "Govt"
+	case SelectorAlly:
+		return "Ally"
+	case SelectorNotGovt:
+		return "NotGovt"
+	case SelectorEnemy:
+		return "Enemy"
+	default:
+		return "None"
+	}
+}
+
+// SelectorState is the context needed to resolve selectors that are relative to the game state. A nil state, or one
+// without Relations, matches nothing for the kinds that need what's missing.
+type SelectorState struct {
+	Relations *GovtRelations
+	AvailStel SpobID // Where the mission was offered, for SelectorAvailStel.
+	Player    SystID // The player's current system, for SelectorFollowPlayer.
+}
+
+// selector is the decoded form shared by both selector types.
+type selector struct {
+	Kind SelectorKind
+	ID   IDType // The stellar, system or govt the selector refers to, depending on Kind.
+	raw  int16
+}
+
+func parseSelector(code int16) selector {
+	c := int(code)
+	s := selector{raw: code}
+
+	switch {
+	case c == -1:
+		s.Kind = SelectorAny
+	case c == -2:
+		s.Kind = SelectorRandomUninhabited
+	case c == -3:
+		s.Kind = SelectorAvailStel
+	case c == -4:
+		s.Kind = SelectorFollowPlayer
+	case selectorFirstResourceID <= c && c <= selectorLastResourceID:
+		s.Kind, s.ID = SelectorSpecific, IDType(c)
+	case selectorAdjacentBase <= c && c < selectorAdjacentBase+selectorAdjacentRange:
+		s.Kind, s.ID = SelectorAdjacent, IDType(c-selectorAdjacentBase+selectorFirstResourceID)
+	case c == selectorIndependent:
+		s.Kind = SelectorIndependent
+	case selectorGovtBase <= c && c < selectorGovtBase+selectorGovtRange:
+		s.Kind, s.ID = SelectorGovt, IDType(c-selectorGovtBase+selectorFirstResourceID)
+	case selectorAllyBase <= c && c < selectorAllyBase+selectorGovtRange:
+		s.Kind, s.ID = SelectorAlly, IDType(c-selectorAllyBase+selectorFirstResourceID)
+	case selectorNotGovtBase <= c && c < selectorNotGovtBase+selectorGovtRange:
+		s.Kind, s.ID = SelectorNotGovt, IDType(c-selectorNotGovtBase+selectorFirstResourceID)
+	case selectorEnemyBase <= c && c < selectorEnemyBase+selectorGovtRange:
+		s.Kind, s.ID = SelectorEnemy, IDType(c-selectorEnemyBase+selectorFirstResourceID)
+	}
+
+	return s
+}
+
+func (s selector) int16() int16 {
+	id := int(s.ID) - selectorFirstResourceID
+
+	switch s.Kind {
+	case SelectorAny:
+		return -1
+	case SelectorRandomUninhabited:
+		return -2
+	case SelectorAvailStel:
+		return -3
+	case SelectorFollowPlayer:
+		return -4
+	case SelectorSpecific:
+		return int16(s.ID)
+	case SelectorAdjacent:
+		return int16(selectorAdjacentBase + id)
+	case SelectorIndependent:
+		return selectorIndependent
+	case SelectorGovt:
+		return int16(selectorGovtBase + id)
+	case SelectorAlly:
+		return int16(selectorAllyBase + id)
+	case SelectorNotGovt:
+		return int16(selectorNotGovtBase + id)
+	case SelectorEnemy:
+		return int16(selectorEnemyBase + id)
+	}
+
+	return s.raw
+}
+
+func (s selector) string(noun string) string {
+	switch s.Kind {
+	case SelectorAny:
+		if noun == "stellar" {
+			return "random inhabited stellar"
+		}
+		return "any system"
+	case SelectorRandomUninhabited:
+		return "random uninhabited " + noun
+	case SelectorAvailStel:
+		if noun == "stellar" {
+			return "AvailStel"
+		}
+		return "AvailStel's system"
+	case SelectorFollowPlayer:
+		return "follow the player"
+	case SelectorSpecific:
+		return fmt.Sprintf("%s %d", noun, s.ID)
+	case SelectorAdjacent:
+		return fmt.Sprintf("%s adjacent to system %d", noun, s.ID)
+	case SelectorIndependent:
+		return "independent " + noun
+	case SelectorGovt:
+		return fmt.Sprintf("%s of govt %d", noun, s.ID)
+	case SelectorAlly:
+		return fmt.Sprintf("%s of an ally of govt %d", noun, s.ID)
+	case SelectorNotGovt:
+		return fmt.Sprintf("%s not of govt %d", noun, s.ID)
+	case SelectorEnemy:
+		return fmt.Sprintf("%s of an enemy of govt %d", noun, s.ID)
+	}
+
+	return fmt.Sprintf("unknown %s selector %d", noun, s.raw)
+}
+
+// matchesGovt handles the govt-relative kinds for a stellar or system owned by owner.
+func (s selector) matchesGovt(owner GovtID, rel *GovtRelations) (matched bool, handled bool) {
+	g := GovtID(s.ID)
+
+	switch s.Kind {
+	case SelectorIndependent:
+		return owner == GovtIDIndependent, true
+	case SelectorGovt:
+		return owner == g, true
+	case SelectorAlly:
+		return owner != g && rel != nil && rel.IsAlly(g, owner), true
+	case SelectorNotGovt:
+		return owner != g, true
+	case SelectorEnemy:
+		return rel != nil && rel.IsEnemy(g, owner), true
+	}
+
+	return false, false
+}
+
+type StellarSelector struct {
+	selector
+}
+
+func ParseStellarSelector(code int16) StellarSelector {
+	return StellarSelector{parseSelector(code)}
+}
+
+func NewStellarSelector(kind SelectorKind, id IDType) StellarSelector {
+	return StellarSelector{selector{Kind: kind, ID: id}}
+}
+
+// Int16 encodes the selector back into its resource field value.
+func (s StellarSelector) Int16() int16 {
+	return s.int16()
+}
+
+func (s StellarSelector) String() string {
+	return s.string("stellar")
+}
+
+// Matches reports whether the stellar satisfies the selector.
+func (s StellarSelector) Matches(lib *ResourceLibrary, state *SelectorState, spob *Spob) bool {
+	if state == nil {
+		state = &SelectorState{}
+	}
+	if matched, handled := s.matchesGovt(spob.Govt, state.Relations); handled {
+		return matched
+	}
+
+	switch s.Kind {
+	case SelectorAny:
+		return !spob.Flags.Uninhabited
+	case SelectorRandomUninhabited:
+		return spob.Flags.Uninhabited
+	case SelectorAvailStel:
+		return spob.ID == state.AvailStel
+	case SelectorSpecific:
+		return spob.ID == SpobID(s.ID)
+	case SelectorAdjacent:
+		syst := lib.SystOfSpob(spob.ID)
+		return syst != nil && lib.Adjacent(syst.ID, SystID(s.ID))
+	}
+
+	return false
+}
+
+// Resolve returns the stellars that satisfy the selector, in ID order. For the kinds that Nova resolves by picking
+// at random (SelectorAny and SelectorRandomUninhabited), a non-nil rng narrows the result to that one pick.
+func (s StellarSelector) Resolve(lib *ResourceLibrary, state *SelectorState, rng *rand.Rand) []SpobID {
+	var out []SpobID
+	for _, id := range sortedSpobIDs(lib.Spobs) {
+		if s.Matches(lib, state, lib.Spobs[id]) {
+			out = append(out, id)
+		}
+	}
+
+	if rng != nil && len(out) > 0 && (s.Kind == SelectorAny || s.Kind == SelectorRandomUninhabited) {
+		return []SpobID{out[rng.Intn(len(out))]}
+	}
+
+	return out
+}
+
+type SystemSelector struct {
+	selector
+}
+
+func ParseSystemSelector(code int16) SystemSelector {
+	return SystemSelector{parseSelector(code)}
+}
+
+func NewSystemSelector(kind SelectorKind, id IDType) SystemSelector {
+	return SystemSelector{selector{Kind: kind, ID: id}}
+}
+
+// Int16 encodes the selector back into its resource field value.
+func (s SystemSelector) Int16() int16 {
+	return s.int16()
+}
+
+func (s SystemSelector) String() string {
+	return s.string("system")
+}
+
+// Matches reports whether the system satisfies the selector.
+func (s SystemSelector) Matches(lib *ResourceLibrary, state *SelectorState, syst *Syst) bool {
+	if state == nil {
+		state = &SelectorState{}
+	}
+	if matched, handled := s.matchesGovt(syst.Govt, state.Relations); handled {
+		return matched
+	}
+
+	switch s.Kind {
+	case SelectorAny:
+		return true
+	case SelectorAvailStel:
+		avail := lib.SystOfSpob(state.AvailStel)
+		return avail != nil && avail.ID == syst.ID
+	case SelectorFollowPlayer:
+		return syst.ID == state.Player
+	case SelectorSpecific:
+		return syst.ID == SystID(s.ID)
+	case SelectorAdjacent:
+		return lib.Adjacent(syst.ID, SystID(s.ID))
+	}
+
+	return false
+}
+
+// Resolve returns the systems that satisfy the selector, in ID order. For SelectorAny, a non-nil rng narrows the
+// result to a single random pick.
+func (s SystemSelector) Resolve(lib *ResourceLibrary, state *SelectorState, rng *rand.Rand) []SystID {
+	var out []SystID
+	for _, id := range sortedSystIDs(lib.Systs) {
+		if s.Matches(lib, state, lib.Systs[id]) {
+			out = append(out, id)
+		}
+	}
+
+	if rng != nil && len(out) > 0 && s.Kind == SelectorAny {
+		return []SystID{out[rng.Intn(len(out))]}
+	}
+
+	return out
+}
@@ -0,0 +1,547 @@
+package resources
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/imle/resourcefork"
+)
+
+// Snapshots save a loaded library so that later runs can skip parsing the resource files. They're laid out as:
+//
+//  "EVGS"            Magic
+//  uvarint           Format version
+//  uvarint + bytes   Hash of the source files, see HashSources
+//  deflate stream of:
+//    uvarint         Number of resource types
+//      - repeating for each type
+//      string        Type
+//      uvarint       Number of resources [N]
+//        - repeating N times
+//        uvarint     ID
+//        string      Name
+//        bytes       Raw resource data, empty for images stored decoded below
+//    - repeating for PICT, rlëD, rlë8 and cicn
+//    uvarint         Number of decoded images [N]
+//      - repeating N times
+//      uvarint       ID
+//      rect          Frame rectangle, rlë only
+//      uvarint * 2   Frames across and down, rlë only
+//      rect          Image bounds
+//      bytes         NRGBA pixels
+//
+// Strings and byte slices are prefixed with their uvarint length, and rectangles are four zigzag varints. Every
+// resource is stored raw so the snapshot can be rewritten, except for the images the library had already decoded,
+// which are only stored as pixels. Images the library hadn't decoded yet (because it was loaded lazily) are decoded
+// when the snapshot is read, or left for later if it's read lazily.
+
+const snapshotVersion = 1
+
+var snapshotMagic = []byte("EVGS")
+
+var (
+	ErrSnapshotFormat  = errors.New("malformed snapshot")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	ErrSnapshotStale   = errors.New("snapshot doesn't match its source files")
+	ErrSnapshotSource  = errors.New("library has no source resources to snapshot")
+)
+
+// HashSources returns a content hash of the resource files found at the paths, in the same order
+// ReadResourcesFromPath reads them.
+func HashSources(paths ...string) ([]byte, error) {
+	h := sha256.New()
+
+	for _, v := range paths {
+		files, err := resourceFilesFromPath(v)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files {
+			b, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+
+			var n [binary.MaxVarintLen64]byte
+			h.Write([]byte(filepath.Base(f)))
+			h.Write(n[:binary.PutUvarint(n[:], uint64(len(b)))])
+			h.Write(b)
+		}
+	}
+
+	return h.Sum(nil), nil
+}
+
+// LoadCachedResourceLibrary loads the library from the snapshot at snapshotPath if it was made from the current
+// contents of the source paths. Otherwise it loads the sources and writes a fresh snapshot. The library is returned
+// even if the snapshot couldn't be written, along with that error. The context and options apply either way, though
+// when the snapshot is used only the resources it keeps raw are decoded and reported to opts.Progress.
+func LoadCachedResourceLibrary(ctx context.Context, snapshotPath string, opts *LoadOptions, paths ...string) (*ResourceLibrary, error) {
+	hash, err := HashSources(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	if f, err := os.Open(snapshotPath); err == nil {
+		rl, err := readSnapshot(ctx, f, hash, opts)
+		_ = f.Close()
+		if err == nil {
+			return rl, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	}
+
+	rf, err := ReadResourcesFromPath(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	rl, err := LoadResourceLibrary(ctx, rf, opts)
+	if err != nil {
+		return nil, err
+	}
+	rl.sourceHash = hash
+
+	// Write to a temporary file first so a reader never sees half a snapshot.
+	tmp, err := ioutil.TempFile(filepath.Dir(snapshotPath), filepath.Base(snapshotPath)+".*")
+	if err != nil {
+		return rl, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := rl.WriteSnapshot(tmp); err != nil {
+		_ = tmp.Close()
+		return rl, err
+	}
+	if err := tmp.Close(); err != nil {
+		return rl, err
+	}
+
+	return rl, os.Rename(tmp.Name(), snapshotPath)
+}
+
+// WriteSnapshot writes the library in the snapshot format. Only libraries created by LoadResourceLibrary or read
+// from a snapshot can be written, as they keep the raw resources.
+func (rl *ResourceLibrary) WriteSnapshot(w io.Writer) error {
+	if rl.source == nil {
+		return ErrSnapshotSource
+	}
+
+	bw := bufio.NewWriter(w)
+	sw := &snapshotWriter{w: bw}
+	sw.write(snapshotMagic)
+	sw.uvarint(snapshotVersion)
+	sw.bytes(rl.sourceHash)
+
+	fw, err := flate.NewWriter(bw, flate.BestSpeed)
+	if err != nil {
+		return err
+	}
+	sw.w = fw
+
+	types := make([]string, 0, len(rl.source.Resources))
+	for typ := range rl.source.Resources {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	sw.uvarint(uint64(len(types)))
+	for _, typ := range types {
+		resources := rl.source.Resources[typ]
+
+		sw.string(typ)
+		sw.uvarint(uint64(len(resources)))
+		for _, id := range sortedResourceIDs(resources) {
+			sw.uvarint(uint64(id))
+			sw.string(resources[id].Name)
+			if rl.imageDecoded(typ, id) {
+				sw.bytes(nil)
+			} else {
+				sw.bytes(resources[id].Data)
+			}
+		}
+	}
+
+	var picts []*Pict
+	for _, p := range rl.Picts {
+		if p.data == nil {
+			picts = append(picts, p)
+		}
+	}
+	sort.Slice(picts, func(i, j int) bool { return picts[i].ID < picts[j].ID })
+	sw.uvarint(uint64(len(picts)))
+	for _, p := range picts {
+		sw.uvarint(uint64(p.ID))
+		sw.image(p.Image)
+	}
+
+	var rleDs []*RleD
+	for _, r := range rl.RleDs {
+		if r.data == nil {
+			rleDs = append(rleDs, r)
+		}
+	}
+	sort.Slice(rleDs, func(i, j int) bool { return rleDs[i].ID < rleDs[j].ID })
+	sw.uvarint(uint64(len(rleDs)))
+	for _, r := range rleDs {
+		sw.uvarint(uint64(r.ID))
+		sw.sheet(r.Rectangle, r.CountAcross, r.CountDown)
+		sw.image(r.Image)
+	}
+
+	var rle8s []*Rle8
+	for _, r := range rl.Rle8s {
+		if r.data == nil {
+			rle8s = append(rle8s, r)
+		}
+	}
+	sort.Slice(rle8s, func(i, j int) bool { return rle8s[i].ID < rle8s[j].ID })
+	sw.uvarint(uint64(len(rle8s)))
+	for _, r := range rle8s {
+		sw.uvarint(uint64(r.ID))
+		sw.sheet(r.Rectangle, r.CountAcross, r.CountDown)
+		sw.image(r.Image)
+	}
+
+	var cicns []*Cicn
+	for _, c := range rl.Cicns {
+		if c.data == nil {
+			cicns = append(cicns, c)
+		}
+	}
+	sort.Slice(cicns, func(i, j int) bool { return cicns[i].ID < cicns[j].ID })
+	sw.uvarint(uint64(len(cicns)))
+	for _, c := range cicns {
+		sw.uvarint(uint64(c.ID))
+		sw.image(c.Image)
+	}
+
+	if sw.err != nil {
+		return sw.err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// imageDecoded reports whether the resource is an image the library holds decoded, so WriteSnapshot stores its pixels.
+func (rl *ResourceLibrary) imageDecoded(typ string, id uint16) bool {
+	switch typ {
+	case "PICT":
+		p, ok := rl.Picts[PictID(id)]
+		return ok && p.data == nil
+	case "rlëD":
+		r, ok := rl.RleDs[RleDID(id)]
+		return ok && r.data == nil
+	case "rlë8":
+		r, ok := rl.Rle8s[Rle8ID(id)]
+		return ok && r.data == nil
+	case "cicn":
+		c, ok := rl.Cicns[CicnID(id)]
+		return ok && c.data == nil
+	}
+
+	return false
+}
+
+// ReadSnapshot replaces the library's contents with the snapshot's. It doesn't check the snapshot against any source
+// files; LoadCachedResourceLibrary does that. Images the snapshot doesn't hold decoded are left to decode lazily.
+func (rl *ResourceLibrary) ReadSnapshot(r io.Reader) error {
+	read, err := readSnapshot(context.Background(), r, nil, &LoadOptions{Lazy: true})
+	if err != nil {
+		return err
+	}
+
+	*rl = *read
+
+	return nil
+}
+
+// readSnapshot reads a snapshot, failing with ErrSnapshotStale before decoding anything if hash is set and differs
+// from the snapshot's. The raw resources are loaded with LoadResourceLibrary and opts, and the decoded images added
+// to the result.
+func readSnapshot(ctx context.Context, r io.Reader, hash []byte, opts *LoadOptions) (*ResourceLibrary, error) {
+	br := bufio.NewReader(r)
+	sr := &snapshotReader{r: br}
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, snapshotMagic) {
+		return nil, ErrSnapshotFormat
+	}
+	if v := sr.uvarint(); sr.err == nil && v != snapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+	sourceHash := sr.bytes()
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	if hash != nil && !bytes.Equal(hash, sourceHash) {
+		return nil, ErrSnapshotStale
+	}
+
+	fr := flate.NewReader(br)
+	defer fr.Close()
+	sr.r = bufio.NewReader(fr)
+
+	source := &resourcefork.ResourceFork{Resources: map[string]map[uint16]resourcefork.Resource{}}
+	for types := sr.uvarint(); types > 0 && sr.err == nil; types-- {
+		typ := sr.string()
+		resources := map[uint16]resourcefork.Resource{}
+
+		for n := sr.uvarint(); n > 0 && sr.err == nil; n-- {
+			id := uint16(sr.uvarint())
+			resources[id] = resourcefork.Resource{Type: typ, ID: id, Name: sr.string(), Data: sr.bytes()}
+		}
+		source.Resources[typ] = resources
+	}
+
+	picts := map[PictID]*Pict{}
+	for n := sr.uvarint(); n > 0 && sr.err == nil; n-- {
+		p := &Pict{ID: PictID(sr.uvarint())}
+		p.Image = sr.image()
+		picts[p.ID] = p
+	}
+
+	rleDs := map[RleDID]*RleD{}
+	for n := sr.uvarint(); n > 0 && sr.err == nil; n-- {
+		r := &RleD{ID: RleDID(sr.uvarint())}
+		r.Rectangle, r.CountAcross, r.CountDown = sr.sheet()
+		r.Image = sr.image()
+		rleDs[r.ID] = r
+	}
+
+	rle8s := map[Rle8ID]*Rle8{}
+	for n := sr.uvarint(); n > 0 && sr.err == nil; n-- {
+		r := &Rle8{ID: Rle8ID(sr.uvarint())}
+		r.Rectangle, r.CountAcross, r.CountDown = sr.sheet()
+		r.Image = sr.image()
+		rle8s[r.ID] = r
+	}
+
+	cicns := map[CicnID]*Cicn{}
+	for n := sr.uvarint(); n > 0 && sr.err == nil; n-- {
+		c := &Cicn{ID: CicnID(sr.uvarint())}
+		c.Image = sr.image()
+		cicns[c.ID] = c
+	}
+
+	if sr.err != nil {
+		return nil, sr.err
+	}
+
+	// Everything the snapshot doesn't hold decoded is loaded from the raw resources.
+	decoded := func(typ string, id uint16) bool {
+		var ok bool
+		switch typ {
+		case "PICT":
+			_, ok = picts[PictID(id)]
+		case "rlëD":
+			_, ok = rleDs[RleDID(id)]
+		case "rlë8":
+			_, ok = rle8s[Rle8ID(id)]
+		case "cicn":
+			_, ok = cicns[CicnID(id)]
+		}
+		return ok
+	}
+
+	raw := &resourcefork.ResourceFork{Resources: map[string]map[uint16]resourcefork.Resource{}}
+	for typ, resources := range source.Resources {
+		raw.Resources[typ] = map[uint16]resourcefork.Resource{}
+		for id, res := range resources {
+			if !decoded(typ, id) {
+				raw.Resources[typ][id] = res
+			}
+		}
+	}
+
+	rl, err := LoadResourceLibrary(ctx, raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	rl.source = source
+	rl.sourceHash = sourceHash
+
+	for id, p := range picts {
+		rl.Picts[id] = p
+	}
+	for id, r := range rleDs {
+		rl.RleDs[id] = r
+	}
+	for id, r := range rle8s {
+		rl.Rle8s[id] = r
+	}
+	for id, c := range cicns {
+		rl.Cicns[id] = c
+	}
+
+	return rl, nil
+}
+
+func sortedResourceIDs(resources map[uint16]resourcefork.Resource) []uint16 {
+	ids := make([]uint16, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+type snapshotWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *snapshotWriter) write(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+	}
+}
+
+func (s *snapshotWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	s.write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (s *snapshotWriter) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	s.write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (s *snapshotWriter) bytes(b []byte) {
+	s.uvarint(uint64(len(b)))
+	s.write(b)
+}
+
+func (s *snapshotWriter) string(v string) {
+	s.bytes([]byte(v))
+}
+
+func (s *snapshotWriter) rect(r image.Rectangle) {
+	s.varint(int64(r.Min.X))
+	s.varint(int64(r.Min.Y))
+	s.varint(int64(r.Max.X))
+	s.varint(int64(r.Max.Y))
+}
+
+func (s *snapshotWriter) sheet(frame image.Rectangle, across, down int) {
+	s.rect(frame)
+	s.uvarint(uint64(across))
+	s.uvarint(uint64(down))
+}
+
+func (s *snapshotWriter) image(img image.Image) {
+	if img == nil {
+		img = &image.NRGBA{}
+	}
+
+	n, ok := img.(*image.NRGBA)
+	if !ok || n.Stride != 4*n.Rect.Dx() {
+		n = image.NewNRGBA(img.Bounds())
+		draw.Draw(n, n.Rect, img, img.Bounds().Min, draw.Src)
+	}
+
+	s.rect(n.Rect)
+	s.bytes(n.Pix)
+}
+
+type snapshotReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (s *snapshotReader) fail(err error) {
+	if s.err == nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrSnapshotFormat
+		}
+		s.err = err
+	}
+}
+
+func (s *snapshotReader) uvarint() uint64 {
+	if s.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		s.fail(err)
+	}
+
+	return v
+}
+
+func (s *snapshotReader) varint() int64 {
+	if s.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadVarint(s.r)
+	if err != nil {
+		s.fail(err)
+	}
+
+	return v
+}
+
+func (s *snapshotReader) bytes() []byte {
+	n := s.uvarint()
+	if s.err != nil {
+		return nil
+	}
+
+	// Read in chunks so a corrupt length can't make us allocate a huge buffer up front.
+	b := &bytes.Buffer{}
+	if _, err := io.CopyN(b, s.r, int64(n)); err != nil {
+		s.fail(err)
+		return nil
+	}
+
+	return b.Bytes()
+}
+
+func (s *snapshotReader) string() string {
+	return string(s.bytes())
+}
+
+func (s *snapshotReader) rect() image.Rectangle {
+	return image.Rect(int(s.varint()), int(s.varint()), int(s.varint()), int(s.varint()))
+}
+
+func (s *snapshotReader) sheet() (image.Rectangle, int, int) {
+	return s.rect(), int(s.uvarint()), int(s.uvarint())
+}
+
+func (s *snapshotReader) image() *image.NRGBA {
+	r := s.rect()
+	pix := s.bytes()
+	if s.err != nil {
+		return nil
+	}
+	if len(pix) != 4*r.Dx()*r.Dy() {
+		s.fail(fmt.Errorf("%w: image pixels don't match its bounds", ErrSnapshotFormat))
+		return nil
+	}
+
+	return &image.NRGBA{Pix: pix, Stride: 4 * r.Dx(), Rect: r}
+}
@@ -2,6 +2,8 @@ package resources
 
 import (
 	"encoding/binary"
+	"image"
+	"image/color"
 
 	"github.com/imle/resourcefork"
 )
@@ -72,3 +74,71 @@ func SpinFromBytes(id SpinID, b []byte) *Spin {
 
 	return t
 }
+
+// NewSpin describes a sprite sheet of frames of the given size, laid out across by down.
+func NewSpin(id SpinID, sprites GraphicID, masks PictID, frame image.Point, across, down int) *Spin {
+	return &Spin{
+		ID:        id,
+		SpritesID: sprites,
+		MasksID:   masks,
+		xSize:     int16(frame.X),
+		ySize:     int16(frame.Y),
+		xTiles:    int16(across),
+		yTiles:    int16(down),
+	}
+}
+
+func (s *Spin) Bytes() []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[0:], uint16(s.SpritesID))
+	binary.BigEndian.PutUint16(b[2:], uint16(s.MasksID))
+	binary.BigEndian.PutUint16(b[4:], uint16(s.xSize))
+	binary.BigEndian.PutUint16(b[6:], uint16(s.ySize))
+	binary.BigEndian.PutUint16(b[8:], uint16(s.xTiles))
+	binary.BigEndian.PutUint16(b[10:], uint16(s.yTiles))
+
+	return b
+}
+
+// Frames returns the spïn's sprites one frame at a time. rlëD and rlë8 sprites are used if there are any with the
+// sprites ID; otherwise the sprites and masks PICTs are combined, the mask giving each pixel's opacity.
+func (s *Spin) Frames(lib *ResourceLibrary) ([]image.Image, error) {
+	if _, ok := lib.RleDs[s.SpritesID.RleDID()]; ok {
+		r, err := lib.RleD(s.SpritesID.RleDID())
+		if err != nil {
+			return nil, err
+		}
+		return r.Frames(), nil
+	}
+	if _, ok := lib.Rle8s[Rle8ID(s.SpritesID)]; ok {
+		r, err := lib.Rle8(Rle8ID(s.SpritesID))
+		if err != nil {
+			return nil, err
+		}
+		return r.Frames(), nil
+	}
+
+	sprites, err := lib.Pict(s.SpritesID.PictID())
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := image.NewNRGBA(sprites.Image.Rect)
+	copy(sheet.Pix, sprites.Image.Pix)
+
+	// Sprites without a mask are left opaque.
+	if masks, err := lib.Pict(s.MasksID); err == nil {
+		for y := sheet.Rect.Min.Y; y < sheet.Rect.Max.Y; y++ {
+			for x := sheet.Rect.Min.X; x < sheet.Rect.Max.X; x++ {
+				if !(image.Point{X: x, Y: y}).In(masks.Image.Rect) {
+					continue
+				}
+				a := color.GrayModel.Convert(masks.Image.At(x, y)).(color.Gray).Y
+				sheet.Pix[sheet.PixOffset(x, y)+3] = a
+			}
+		}
+	}
+
+	frame := image.Rect(0, 0, int(s.xSize), int(s.ySize))
+	return sheetFrames(sheet, frame, int(s.xTiles), int(s.yTiles)), nil
+}
@@ -6,21 +6,24 @@ import (
 	"github.com/imle/resourcefork"
 )
 
-// TODO: Str patching
-
 // String Arrays are constructed as:
 //
 //  0x0000 - Total strings in array [N]
 //    - repeating N times
 //    0x00 - String length [L]
-//    0x00 * L - Strings are not null terminated
+//    0x00 * L - Strings are not null terminated, and are Mac OS Roman encoded
+//
+// Nova refers to the strings by their 1-based index.
+//
+// A plugin's STR# resource patches the one of the same ID that came before it rather than replacing it outright:
+// empty strings in the plugin leave the original string in place, and any extra strings are appended.
 
 type StrAID IDType
 
 type StrA struct {
 	ID StrAID
 
-	Values []*string
+	Values []string
 }
 
 func StrAFromResource(resource resourcefork.Resource) *StrA {
@@ -28,25 +31,76 @@ func StrAFromResource(resource resourcefork.Resource) *StrA {
 }
 
 func StrAFromBytes(id StrAID, b []byte) *StrA {
+	t := &StrA{ID: id}
+	if len(b) < 2 {
+		return t
+	}
+
 	// First word is string count
-	strCount := int16(binary.BigEndian.Uint16(b[0:]))
+	strCount := int(binary.BigEndian.Uint16(b[0:]))
+	t.Values = make([]string, 0, strCount)
 
-	t := &StrA{
-		ID:     id,
-		Values: make([]*string, strCount),
+	// Start after first word. Truncated resources keep the strings read so far.
+	pos := 2
+	for i := 0; i < strCount && pos < len(b); i++ {
+		strLen := int(b[pos])
+		pos++
+
+		if pos+strLen > len(b) {
+			break
+		}
+
+		t.Values = append(t.Values, decodeMacRoman(b[pos:pos+strLen]))
+		pos += strLen
 	}
 
-	// Start after first word
-	pos := int16(2)
+	return t
+}
 
-	var strLen uint8
-	for i := int16(0); i < strCount; i++ {
-		strLen = b[pos]
-		pos++
+// Get returns the string at the 1-based index, or an empty string if there is no such string.
+func (s StrA) Get(index int) string {
+	if index < 1 || index > len(s.Values) {
+		return ""
+	}
+
+	return s.Values[index-1]
+}
+
+// Bytes encodes the strings back into a STR# resource. Strings are truncated to 255 bytes once encoded.
+func (s StrA) Bytes() []byte {
+	b := make([]byte, 2, 2+len(s.Values)*16)
+	binary.BigEndian.PutUint16(b, uint16(len(s.Values)))
+
+	for _, v := range s.Values {
+		enc := encodeMacRoman(v)
+		if len(enc) > 255 {
+			enc = enc[:255]
+		}
+
+		b = append(b, byte(len(enc)))
+		b = append(b, enc...)
+	}
+
+	return b
+}
+
+// Patch returns the result of applying a plugin's STR# resource on top of this one.
+func (s StrA) Patch(plugin *StrA) *StrA {
+	n := len(s.Values)
+	if len(plugin.Values) > n {
+		n = len(plugin.Values)
+	}
+
+	t := &StrA{
+		ID:     s.ID,
+		Values: make([]string, n),
+	}
+	copy(t.Values, s.Values)
 
-		s := string(b[pos : pos+int16(strLen)])
-		t.Values[i] = &s
-		pos += int16(strLen)
+	for i, v := range plugin.Values {
+		if v != "" {
+			t.Values[i] = v
+		}
 	}
 
 	return t
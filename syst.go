@@ -31,6 +31,25 @@ type AsteroidFlags struct {
 	HugeCrystal   bool // 0x8000 Huge Crystal    (röid ID 143)
 }
 
+// RoidIDs returns the röid types the system's asteroids are picked from, in ascending order.
+func (f AsteroidFlags) RoidIDs() []RoidID {
+	set := []bool{
+		f.SmallMetal, f.MediumMetal, f.LargeMetal, f.HugeMetal,
+		f.SmallIce, f.MediumIce, f.LargeIce, f.HugeIce,
+		f.SmallDust, f.MediumDust, f.LargeDust, f.HugeDust,
+		f.SmallCrystal, f.MediumCrystal, f.LargeCrystal, f.HugeCrystal,
+	}
+
+	var ids []RoidID
+	for i, ok := range set {
+		if ok {
+			ids = append(ids, RoidIDFirst+RoidID(i))
+		}
+	}
+
+	return ids
+}
+
 type Syst struct {
 	ID SystID
 
@@ -0,0 +1,261 @@
+package resources
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Every time the player enters a system Nova populates it with traffic. Roughly AvgShips ships are created, each one
+// drawn from the system's dude classes according to their Prob fields, with the ship class then drawn from the dude
+// according to its Probability fields. Each created ship has a chance of being replaced by a special përs ship, and
+// any flët whose LinkSyst and AppearOn allow it may show up as well.
+
+// PersChance is the chance that a created ship is replaced by a special person, as documented for the përs resource.
+const PersChance = 0.05
+
+type TrafficSimulator struct {
+	Lib       *ResourceLibrary
+	Relations *GovtRelations
+	State     ControlBitState // Control bit state used for AppearOn and ActiveOn tests.
+
+	PersChance  float64           // Chance that each created ship is a special person instead.
+	FleetChance float64           // Chance per visit that one of the eligible fleets appears.
+	PersonAlive func(PersID) bool // Reports whether a person is still alive. Nil treats everyone as alive.
+}
+
+func NewTrafficSimulator(lib *ResourceLibrary, state ControlBitState) *TrafficSimulator {
+	if state == nil {
+		state = &ControlBits{}
+	}
+
+	return &TrafficSimulator{
+		Lib:         lib,
+		Relations:   NewGovtRelations(lib.Govts),
+		State:       state,
+		PersChance:  PersChance,
+		FleetChance: 0.25,
+	}
+}
+
+type TrafficReport struct {
+	Syst   SystID
+	Visits int
+	Ships  int // Total number of ships created across all visits.
+
+	ShipTypes map[ShipID]int
+	Govts     map[GovtID]int
+	Dudes     map[DudeID]int
+	Fleets    map[FletID]int
+	Persons   map[PersID]int
+}
+
+// PerVisit converts one of the report's counts into an average per system visit.
+func (r *TrafficReport) PerVisit(count int) float64 {
+	if r.Visits == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(r.Visits)
+}
+
+// Share converts one of the report's ship counts into a fraction of all ships created.
+func (r *TrafficReport) Share(count int) float64 {
+	if r.Ships == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(r.Ships)
+}
+
+func (r *TrafficReport) addShip(ship ShipID, govt GovtID) {
+	r.Ships++
+	r.ShipTypes[ship]++
+	r.Govts[govt]++
+}
+
+// Simulate runs the given number of system visits with a deterministic random source.
+func (s *TrafficSimulator) Simulate(id SystID, visits int, seed int64) (*TrafficReport, error) {
+	syst, ok := s.Lib.Systs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown sÿst %d", id)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	r := &TrafficReport{
+		Syst:      id,
+		Visits:    visits,
+		ShipTypes: map[ShipID]int{},
+		Govts:     map[GovtID]int{},
+		Dudes:     map[DudeID]int{},
+		Fleets:    map[FletID]int{},
+		Persons:   map[PersID]int{},
+	}
+
+	dudes, dudeWeights := s.systemDudes(syst)
+	fleets := s.EligibleFleets(syst)
+	persons := s.EligiblePersons(syst)
+
+	for v := 0; v < visits; v++ {
+		count := shipCount(syst.AvgShips, rng)
+
+		for i := 0; i < count; i++ {
+			if len(persons) > 0 && rng.Float64() < s.PersChance {
+				p := persons[rng.Intn(len(persons))]
+				r.Persons[p.ID]++
+				r.addShip(p.ShipType, p.Govt)
+				continue
+			}
+
+			di := weightedPick(dudeWeights, rng)
+			if di < 0 {
+				continue
+			}
+
+			dude := dudes[di]
+			ship, ok := s.pickShip(dude, rng)
+			if !ok {
+				continue
+			}
+
+			r.Dudes[dude.ID]++
+			r.addShip(ship, dude.Govt)
+		}
+
+		if len(fleets) > 0 && rng.Float64() < s.FleetChance {
+			f := fleets[rng.Intn(len(fleets))]
+			r.Fleets[f.ID]++
+			r.addShip(f.LeadShipType, f.Govt)
+
+			for j := range f.EscortType {
+				lo, hi := int(f.Min[j]), int(f.Max[j])
+				if hi < lo {
+					hi = lo
+				}
+
+				n := lo
+				if hi > lo {
+					n += rng.Intn(hi - lo + 1)
+				}
+
+				for k := 0; k < n; k++ {
+					r.addShip(f.EscortType[j], f.Govt)
+				}
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// shipCount varies the number of ships per visit by up to half of the system's average in either direction.
+func shipCount(avg int16, rng *rand.Rand) int {
+	if avg <= 0 {
+		return 0
+	}
+
+	spread := int(avg) / 2
+	if spread == 0 {
+		return int(avg)
+	}
+
+	return int(avg) - spread + rng.Intn(2*spread+1)
+}
+
+func weightedPick(weights []int, rng *rand.Rand) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+
+	if total <= 0 {
+		return -1
+	}
+
+	n := rng.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+
+	return -1
+}
+
+func (s *TrafficSimulator) systemDudes(syst *Syst) ([]*Dude, []int) {
+	var dudes []*Dude
+	var weights []int
+
+	for i, id := range syst.DudeTypes {
+		dude, ok := s.Lib.Dudes[id]
+		if !ok || syst.Prob[i] <= 0 {
+			continue
+		}
+
+		dudes = append(dudes, dude)
+		weights = append(weights, int(syst.Prob[i]))
+	}
+
+	return dudes, weights
+}
+
+func (s *TrafficSimulator) pickShip(dude *Dude, rng *rand.Rand) (ShipID, bool) {
+	weights := make([]int, len(dude.ShipType))
+	for i, ship := range dude.ShipType {
+		if ship <= 0 || dude.Probability[i] <= 0 {
+			continue
+		}
+		weights[i] = int(dude.Probability[i])
+	}
+
+	i := weightedPick(weights, rng)
+	if i < 0 {
+		return 0, false
+	}
+
+	return dude.ShipType[i], true
+}
+
+// EligibleFleets returns the fleets that may appear in the system under the simulator's control bit state.
+func (s *TrafficSimulator) EligibleFleets(syst *Syst) []*Flet {
+	var out []*Flet
+	for _, id := range sortedFletIDs(s.Lib.Flets) {
+		f := s.Lib.Flets[id]
+		if !f.LinkSyst.Matches(s.Lib, &SelectorState{Relations: s.Relations}, syst) {
+			continue
+		}
+		if !f.AppearOn.Test(s.State) {
+			continue
+		}
+		out = append(out, f)
+	}
+
+	return out
+}
+
+// EligiblePersons returns the living, active persons that may appear in the system, either because the system
+// lists them or because their LinkSyst matches it.
+func (s *TrafficSimulator) EligiblePersons(syst *Syst) []*Pers {
+	listed := map[PersID]bool{}
+	for _, id := range syst.Persons {
+		listed[id] = true
+	}
+
+	var out []*Pers
+	for _, id := range sortedPersIDs(s.Lib.Perss) {
+		p := s.Lib.Perss[id]
+		if !listed[id] && !p.LinkSyst.Matches(s.Lib, &SelectorState{Relations: s.Relations}, syst) {
+			continue
+		}
+		if s.PersonAlive != nil && !s.PersonAlive(id) {
+			continue
+		}
+		if !p.ActiveOn.Test(s.State) {
+			continue
+		}
+		out = append(out, p)
+	}
+
+	return out
+}
@@ -300,3 +300,13 @@ func WeapFromBytes(id WeapID, b []byte) *Weap {
 
 	return t
 }
+
+// AmmoID returns the wëap whose ammunition the weapon uses up: 128 + AmmoType. Weapons with an AmmoType outside 0-255
+// don't use ammunition.
+func (w Weap) AmmoID() (WeapID, bool) {
+	if w.AmmoType < 0 || w.AmmoType > 255 {
+		return 0, false
+	}
+
+	return WeapID(128 + w.AmmoType), true
+}